@@ -1,11 +1,12 @@
 package lx
 
-// NormalizeArgs rewrites "-n2" / "-t10" / "-h5" into ["-n","2"] / ["-t","10"] / ["-h","5"]
-// so that urfave/cli/v3 parses them as int flags.
+// NormalizeArgs rewrites "-n2" / "-t10" / "-h5" / "-j8" / "-C3" into ["-n","2"] /
+// ["-t","10"] / ["-h","5"] / ["-j","8"] / ["-C","3"] so that urfave/cli/v3
+// parses them as int flags.
 func NormalizeArgs(args []string) []string {
 	out := make([]string, 0, len(args)+4)
 	for _, a := range args {
-		if len(a) > 2 && a[0] == '-' && (a[1] == 'n' || a[1] == 't' || a[1] == 'h') {
+		if len(a) > 2 && a[0] == '-' && (a[1] == 'n' || a[1] == 't' || a[1] == 'h' || a[1] == 'j' || a[1] == 'C') {
 			digits := a[2:]
 			isDigits := true
 			for _, ch := range digits {
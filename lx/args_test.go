@@ -26,6 +26,16 @@ func TestNormalizeArgs(t *testing.T) {
 			in:   []string{"lx", "-h5"},
 			want: []string{"lx", "-h", "5"},
 		},
+		{
+			name: "j flag short",
+			in:   []string{"lx", "-j8", "file"},
+			want: []string{"lx", "-j", "8", "file"},
+		},
+		{
+			name: "C flag short",
+			in:   []string{"lx", "--match", "err", "-C2", "file"},
+			want: []string{"lx", "--match", "err", "-C", "2", "file"},
+		},
 		{
 			name: "mixed",
 			in:   []string{"lx", "-n2", "-t3", "file"},
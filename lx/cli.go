@@ -2,15 +2,22 @@ package lx
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"runtime/debug"
+	"time"
 
 	ucli "github.com/urfave/cli/v3"
 )
 
 var Version = "(devel)"
 
+// watchDebounce is how long files must stay unchanged before --watch
+// re-renders, so that a burst of saves produces a single update.
+const watchDebounce = 300 * time.Millisecond
+
 func init() {
 	// If ldflags already set Version (e.g. release builds), leave it unchanged.
 	if Version != "(devel)" {
@@ -28,7 +35,18 @@ func init() {
 
 // NewCommand builds the urfave/cli command for lx.
 func NewCommand() *ucli.Command {
-	var opts Options
+	var (
+		opts          Options
+		manifestPath  string
+		commands      []string
+		outputPath    string
+		watch         bool
+		watchInterval time.Duration
+		copyOut       bool
+		clipboardCmd  string
+		splitTokens   int
+		splitBytes    int
+	)
 
 	// Make --help the only help flag (freeing -h for --head).
 	ucli.HelpFlag = &ucli.BoolFlag{
@@ -42,6 +60,8 @@ func NewCommand() *ucli.Command {
 		Name:    "lx",
 		Usage:   "print files with headers, delimiters, and optional head/tail slicing",
 		Version: Version,
+		// Repeatable flags take regular expressions, which may contain commas.
+		DisableSliceFlagSeparator: true,
 
 		Flags: []ucli.Flag{
 			&ucli.IntFlag{
@@ -63,11 +83,72 @@ func NewCommand() *ucli.Command {
 				Usage:       "print N lines split between head and tail (0 = no limit)",
 				Destination: &opts.NBoth,
 			},
+			&ucli.StringFlag{
+				Name:        "from-regex",
+				Usage:       "print sections starting at lines matching REGEX (through --to-regex, if set)",
+				Validator:   validatePattern,
+				Destination: &opts.FromRegex,
+			},
+			&ucli.StringFlag{
+				Name:        "to-regex",
+				Usage:       "end each section at the next line matching REGEX",
+				Validator:   validatePattern,
+				Destination: &opts.ToRegex,
+			},
+			&ucli.StringFlag{
+				Name:        "since",
+				Usage:       "print only log entries timestamped at or after TIME, e.g. 2024-05-01 14:30, 14:30, or 15m for 15 minutes ago",
+				Validator:   validateTimeBound,
+				Destination: &opts.Since,
+			},
+			&ucli.StringFlag{
+				Name:        "until",
+				Usage:       "print only log entries timestamped at or before TIME",
+				Validator:   validateTimeBound,
+				Destination: &opts.Until,
+			},
+			&ucli.StringFlag{
+				Name:        "keep-lines",
+				Usage:       "print only lines matching REGEX; applied before deduplication, sections, --match and head/tail",
+				Validator:   validatePattern,
+				Destination: &opts.KeepLines,
+			},
+			&ucli.StringFlag{
+				Name:        "drop-lines",
+				Usage:       "leave out lines matching REGEX; the count is available as {dropped_rows}",
+				Validator:   validatePattern,
+				Destination: &opts.DropLines,
+			},
+			&ucli.BoolFlag{
+				Name:        "dedupe-lines",
+				Usage:       "collapse consecutive repeated lines into one, noting how often it repeated",
+				Destination: &opts.DedupeLines,
+			},
+			&ucli.StringSliceFlag{
+				Name: "dedupe-normalize",
+				Usage: "REGEX removed from lines before --dedupe-lines compares them; repeatable, " +
+					"replaces the default timestamp and ID patterns",
+				Validator:   validatePatterns,
+				Destination: &opts.DedupeNormalize,
+			},
+			&ucli.StringFlag{
+				Name:        "match",
+				Usage:       "print only lines matching REGEX, plus --context lines around them",
+				Validator:   validatePattern,
+				Destination: &opts.Match,
+			},
+			&ucli.IntFlag{
+				Name:        "context",
+				Aliases:     []string{"C"},
+				Usage:       "lines of context to print around each --match",
+				Validator:   validateContext,
+				Destination: &opts.Context,
+			},
 
 			&ucli.StringFlag{
 				Name: "prefix-delimiter",
 				Usage: "string printed before file contents; placeholders: {filename}, {row_count}, " +
-					"{byte_size}, {last_modified}, {language}, {n}",
+					"{dropped_rows}, {byte_size}, {last_modified}, {language}, {encoding}, {line_endings}, {minified}, {notes}, {n}",
 				Destination: &opts.PrefixDelimiter,
 			},
 			&ucli.StringFlag{
@@ -76,12 +157,149 @@ func NewCommand() *ucli.Command {
 				Destination: &opts.PostfixDelimiter,
 			},
 
+			&ucli.StringFlag{
+				Name:        "ellipsis",
+				Usage:       "marker for rows skipped between head and tail; placeholders: {skipped}, {from}, {to}, {n}",
+				Value:       defaultEllipsis,
+				Destination: &opts.Ellipsis,
+			},
+
+			&ucli.StringFlag{
+				Name:        "eol",
+				Usage:       "line endings for delimiters and content: lf, crlf, native or preserve",
+				Value:       EOLNative,
+				Validator:   validateEOL,
+				Destination: &opts.EOL,
+			},
+
+			&ucli.BoolFlag{
+				Name:        "notebook-outputs",
+				Usage:       "include cell outputs when rendering Jupyter notebooks, with images as placeholders",
+				Destination: &opts.NotebookOutputs,
+			},
+
+			&ucli.BoolFlag{
+				Name:        "tables",
+				Usage:       "render .csv and .tsv files as Markdown tables of sampled rows with a column summary",
+				Destination: &opts.Tables,
+			},
+
+			&ucli.BoolFlag{
+				Name:        "summarize-data",
+				Usage:       "shorten long arrays and strings in .json and .yaml files, keeping them valid",
+				Destination: &opts.SummarizeData,
+			},
+			&ucli.BoolFlag{
+				Name:        "data-schema",
+				Usage:       "with --summarize-data, append the structure inferred from JSON documents",
+				Destination: &opts.DataSchema,
+			},
+
+			&ucli.BoolFlag{
+				Name:        "embed-images",
+				Usage:       "render images as base64 data URIs instead of a placeholder with their dimensions",
+				Destination: &opts.EmbedImages,
+			},
+
+			&ucli.IntFlag{
+				Name:        "max-line-length",
+				Usage:       "truncate lines longer than N characters, noting how many were cut (0 = no limit)",
+				Destination: &opts.MaxLineLength,
+			},
+
 			&ucli.BoolFlag{
 				Name:        "line-numbers",
 				Aliases:     []string{"l"},
 				Usage:       "print line numbers",
 				Destination: &opts.LineNumbers,
 			},
+			&ucli.StringFlag{
+				Name:        "line-number-format",
+				Usage:       "line number style: plain, padded, gutter, L, tab, or a template containing {line}",
+				Value:       LineNumbersPlain,
+				Validator:   validateLineNumberFormat,
+				Destination: &opts.LineNumberFormat,
+			},
+
+			&ucli.IntFlag{
+				Name:        "jobs",
+				Aliases:     []string{"j"},
+				Usage:       "read and render up to N files concurrently; output order is unchanged",
+				Value:       1,
+				Destination: &opts.Jobs,
+			},
+
+			&ucli.BoolFlag{
+				Name:        "keep-going",
+				Aliases:     []string{"k"},
+				Usage:       "render a placeholder for unreadable files and continue; exit status 3 on partial success",
+				Destination: &opts.KeepGoing,
+			},
+			&ucli.BoolFlag{
+				Name:        "skip-errors",
+				Usage:       "like --keep-going, but leave unreadable files out of the output",
+				Destination: &opts.SkipErrors,
+			},
+
+			&ucli.StringFlag{
+				Name:        "manifest",
+				Aliases:     []string{"f"},
+				Usage:       "render the paths, globs, ranges and symbols listed in a manifest file",
+				Destination: &manifestPath,
+			},
+			&ucli.StringSliceFlag{
+				Name:        "cmd",
+				Usage:       "run a shell command and render its output and exit status after the files; repeatable",
+				Destination: &commands,
+			},
+			&ucli.DurationFlag{
+				Name:        "cmd-timeout",
+				Usage:       "stop a --cmd command that runs longer than this and report it as failed; 0 means no limit",
+				Destination: &opts.CommandTimeout,
+			},
+
+			&ucli.StringFlag{
+				Name:        "output",
+				Aliases:     []string{"o"},
+				Usage:       "write output to a file instead of stdout",
+				Destination: &outputPath,
+			},
+
+			&ucli.BoolFlag{
+				Name:        "copy",
+				Aliases:     []string{"c"},
+				Usage:       "copy output to the clipboard instead of printing it",
+				Destination: &copyOut,
+			},
+			&ucli.StringFlag{
+				Name:        "clipboard-cmd",
+				Usage:       "command used by --copy (default: detect wl-copy, xclip, xsel, pbcopy or clip)",
+				Sources:     ucli.EnvVars("LX_CLIPBOARD"),
+				Destination: &clipboardCmd,
+			},
+
+			&ucli.IntFlag{
+				Name:        "split-tokens",
+				Usage:       "split output into parts of about N tokens, keeping files whole where possible",
+				Destination: &splitTokens,
+			},
+			&ucli.IntFlag{
+				Name:        "split-bytes",
+				Usage:       "split output into parts of at most N bytes, keeping files whole where possible",
+				Destination: &splitBytes,
+			},
+
+			&ucli.BoolFlag{
+				Name:        "watch",
+				Usage:       "re-render to --output or --copy whenever a selected file changes",
+				Destination: &watch,
+			},
+			&ucli.DurationFlag{
+				Name:        "watch-interval",
+				Usage:       "how often --watch polls files for changes",
+				Value:       500 * time.Millisecond,
+				Destination: &watchInterval,
+			},
 		},
 
 		Action: func(ctx context.Context, cmd *ucli.Command) error {
@@ -102,16 +320,151 @@ func NewCommand() *ucli.Command {
 				files = append(files, stdinFiles...)
 			}
 
-			if len(files) == 0 {
-				return fmt.Errorf("lx: provide one or more file paths via args or stdin")
+			cfg, err := LoadUserConfig()
+			if err != nil {
+				return fmt.Errorf("lx: %w", err)
+			}
+			opts.Languages = cfg.Languages
+
+			// loadEntries builds the entry list; watch mode calls it again
+			// after every render so that manifest edits are picked up.
+			loadEntries := func() ([]Entry, error) {
+				entries := make([]Entry, 0, len(files))
+				for _, path := range files {
+					entries = append(entries, ParseEntry(path))
+				}
+				if manifestPath != "" {
+					manifestEntries, err := LoadManifest(manifestPath, opts)
+					if err != nil {
+						return nil, err
+					}
+					entries = append(entries, manifestEntries...)
+				}
+				for _, command := range commands {
+					entries = append(entries, Entry{Command: command})
+				}
+				return entries, nil
+			}
+
+			entries, err := loadEntries()
+			if err != nil {
+				return fmt.Errorf("lx: %w", err)
+			}
+			if len(entries) == 0 {
+				return fmt.Errorf("lx: provide one or more file paths via args, stdin or a manifest, or a --cmd")
+			}
+			if watch && outputPath == "" && !copyOut {
+				return fmt.Errorf("lx: --watch requires --output or --copy")
+			}
+			if splitTokens > 0 && splitBytes > 0 {
+				return fmt.Errorf("lx: use only one of --split-tokens and --split-bytes")
+			}
+			if watch && outputPath == "" && (splitTokens > 0 || splitBytes > 0) {
+				return fmt.Errorf("lx: --watch with --split-tokens/--split-bytes requires --output")
+			}
+
+			var clipboard []string
+			if copyOut {
+				if clipboardCmd == "" {
+					clipboardCmd = cfg.Clipboard
+				}
+				if clipboard, err = clipboardCommand(clipboardCmd); err != nil {
+					return fmt.Errorf("lx: %w", err)
+				}
 			}
 
 			r := opts.Effective()
 
-			if err := r.Run(files, os.Stdout); err != nil {
+			target := outputTarget{
+				Path:       outputPath,
+				Clipboard:  clipboard,
+				SplitBytes: splitBytes,
+				Newline:    r.newline(),
+			}
+			if splitTokens > 0 {
+				target.SplitBytes = splitTokens * bytesPerToken
+			}
+
+			emit := func(entries []Entry) error {
+				// Plain stdout output is streamed file by file.
+				if target.Path == "" && target.Clipboard == nil && target.SplitBytes <= 0 {
+					return r.RunEntries(entries, os.Stdout)
+				}
+				blocks, err := r.renderEntries(entries)
+				var partial *PartialError
+				if err != nil && !errors.As(err, &partial) {
+					return err
+				}
+				if emitErr := target.emit(blocks, os.Stdout); emitErr != nil {
+					return emitErr
+				}
+				return err
+			}
+
+			err = emit(entries)
+			var partial *PartialError
+			switch {
+			case errors.As(err, &partial):
+				reportFailures(partial)
+				if !watch {
+					return ucli.Exit("", partial.exitCode())
+				}
+			case err != nil:
 				return fmt.Errorf("lx: %w", err)
+			case !watch:
+				return nil
+			}
+
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+			defer stop()
+
+			watchedPaths := func() []string {
+				paths := make([]string, 0, len(entries)+1)
+				for _, e := range entries {
+					if e.Command == "" {
+						paths = append(paths, e.Path)
+					}
+				}
+				if manifestPath != "" {
+					paths = append(paths, manifestPath)
+				}
+				return paths
 			}
-			return nil
+
+			dest := outputPath
+			if dest == "" {
+				dest = "the clipboard"
+			}
+			fmt.Fprintf(os.Stderr, "lx: watching %d files, writing %s\n", len(entries), dest)
+
+			// Render errors (e.g. a file briefly missing while an editor
+			// saves it) are reported and the previous output is kept.
+			return watchFiles(ctx, watchedPaths, watchInterval, watchDebounce, func() error {
+				next, err := loadEntries()
+				if err == nil {
+					entries = next
+					err = emit(entries)
+				}
+				var partial *PartialError
+				if errors.As(err, &partial) {
+					reportFailures(partial)
+				} else if err != nil {
+					fmt.Fprintf(os.Stderr, "lx: %v\n", err)
+					return nil
+				}
+				if outputPath != "" {
+					fmt.Fprintf(os.Stderr, "lx: updated %s\n", outputPath)
+				}
+				return nil
+			})
 		},
 	}
 }
+
+// reportFailures lists the files that failed under --keep-going on stderr.
+func reportFailures(partial *PartialError) {
+	fmt.Fprintf(os.Stderr, "lx: %v:\n", partial)
+	for _, f := range partial.Failed {
+		fmt.Fprintf(os.Stderr, "  %v\n", f.Err)
+	}
+}
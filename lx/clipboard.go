@@ -0,0 +1,71 @@
+package lx
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// clipboardTools lists the copy commands tried by --copy, in order.
+var clipboardTools = [][]string{
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"pbcopy"},
+	{"clip"},
+}
+
+// clipboardCommand returns the command line used to copy to the clipboard.
+// A non-empty override (from the flag, environment or config file) is used
+// as-is; otherwise the first available tool on PATH is picked.
+func clipboardCommand(override string) ([]string, error) {
+	if argv := strings.Fields(override); len(argv) > 0 {
+		if _, err := exec.LookPath(argv[0]); err != nil {
+			return nil, fmt.Errorf("clipboard command %q: %w", argv[0], err)
+		}
+		return argv, nil
+	}
+
+	for _, argv := range clipboardTools {
+		// wl-copy is only usable inside a Wayland session.
+		if argv[0] == "wl-copy" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			continue
+		}
+		if _, err := exec.LookPath(argv[0]); err == nil {
+			return argv, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no clipboard tool found; install wl-copy, xclip, xsel, pbcopy or clip, " +
+		"or set one with --clipboard-cmd")
+}
+
+// copyToClipboard pipes data into the clipboard command argv.
+func copyToClipboard(argv []string, data []byte) error {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("copy with %s: %w: %s", argv[0], err, msg)
+		}
+		return fmt.Errorf("copy with %s: %w", argv[0], err)
+	}
+	return nil
+}
+
+// copySummary describes copied output of files with rows content rows in
+// total, e.g. "copied 7 files, 1,240 rows, ~9.8k tokens".
+func copySummary(files, rows int, data []byte) string {
+	noun := "files"
+	if files == 1 {
+		noun = "file"
+	}
+	return fmt.Sprintf("copied %s %s, %s rows, %s tokens",
+		formatThousands(files), noun, formatThousands(rows), formatApprox(estimateTokens(data)))
+}
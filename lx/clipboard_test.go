@@ -0,0 +1,80 @@
+package lx
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCopySummary(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789abcdef\n", 3))
+	got := copySummary(7, 3, data)
+	want := "copied 7 files, 3 rows, ~13 tokens"
+	if got != want {
+		t.Errorf("copySummary = %q, want %q", got, want)
+	}
+
+	if got := copySummary(1, 1, []byte("x\n")); !strings.HasPrefix(got, "copied 1 file,") {
+		t.Errorf("copySummary for one file = %q", got)
+	}
+}
+
+func TestCopySummary_CountsContentRows(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte("1\n2\n3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	blocks, err := Options{}.Effective().renderEntries([]Entry{{Path: path}})
+	if err != nil {
+		t.Fatalf("renderEntries error: %v", err)
+	}
+	p := wholePart(blocks)
+	if got := copySummary(p.files, p.rows, p.data); !strings.HasPrefix(got, "copied 1 file, 3 rows,") {
+		t.Errorf("copySummary of a rendered 3-row file = %q, want 3 rows", got)
+	}
+}
+
+func TestClipboardCommand_Override(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	argv, err := clipboardCommand("  sh -c true  ")
+	if err != nil {
+		t.Fatalf("clipboardCommand error: %v", err)
+	}
+	if strings.Join(argv, " ") != "sh -c true" {
+		t.Errorf("argv = %q, want [sh -c true]", argv)
+	}
+
+	if _, err := clipboardCommand("no-such-clipboard-tool"); err == nil {
+		t.Errorf("expected error for missing override command")
+	}
+}
+
+func TestCopyToClipboard_PipesData(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	path := filepath.Join(t.TempDir(), "clip.txt")
+	if err := copyToClipboard([]string{"sh", "-c", `cat > "$0"`, path}, []byte("hello\n")); err != nil {
+		t.Fatalf("copyToClipboard error: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello\n" {
+		t.Errorf("clipboard content = %q, want %q", got, "hello\n")
+	}
+
+	if err := copyToClipboard([]string{"sh", "-c", "echo nope >&2; exit 3"}, nil); err == nil ||
+		!strings.Contains(err.Error(), "nope") {
+		t.Errorf("expected error including stderr, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package lx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// shellCommand returns the command that runs command through the platform
+// shell.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// captureCommand runs command and returns its stdout and stderr interleaved
+// as written, and its exit status. A failing command is not an error; only
+// being unable to run it, or it running longer than a non-zero timeout, is.
+func captureCommand(command string, timeout time.Duration) ([]byte, int, error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := shellCommand(ctx, command)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	// Children left behind by a killed shell may hold the output open.
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, 0, fmt.Errorf("timed out after %s", timeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return output.Bytes(), exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return output.Bytes(), 0, nil
+}
+
+// commandTitle is what stands in for the file name of a command's block.
+func commandTitle(command string, code int) string {
+	return fmt.Sprintf("$ %s (exit %d)", command, code)
+}
+
+// runCommand renders the output of a shell command like a file, titled by
+// the command line and its exit status. Row selection applies as it does to
+// files, except for symbols.
+func (r Runner) runCommand(command string) (outputBlock, error) {
+	ran := time.Now()
+	output, code, err := captureCommand(command, r.CommandTimeout)
+	if err != nil {
+		return outputBlock{}, fmt.Errorf("run %q: %w", command, err)
+	}
+
+	data, enc := decodeText(output)
+	r.Symbol = ""
+	v, err := r.selectView("", data)
+	if err != nil {
+		return outputBlock{}, err
+	}
+	v.language = "text"
+	v.encoding = enc
+	v.endings = lineEndings(data)
+
+	return r.renderView(v, header{
+		path:     commandTitle(command, code),
+		byteSize: int64(len(output)),
+		lastMod:  ran.Format(time.RFC3339),
+	})
+}
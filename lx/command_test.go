@@ -0,0 +1,80 @@
+package lx
+
+import (
+	"bytes"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCaptureCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	out, code, err := captureCommand("echo out; echo err >&2; exit 3", 0)
+	if err != nil {
+		t.Fatalf("captureCommand error: %v", err)
+	}
+	if string(out) != "out\nerr\n" || code != 3 {
+		t.Errorf("captureCommand = %q, exit %d; want %q, exit 3", out, code, "out\nerr\n")
+	}
+}
+
+func TestCaptureCommand_Timeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	start := time.Now()
+	_, _, err := captureCommand("sleep 5", 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("captureCommand error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("captureCommand took %v, want it stopped at the timeout", elapsed)
+	}
+}
+
+func TestRunEntries_CommandsRunOneAtATime(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	// Each command holds a lock directory for a while and exits 9 if another
+	// command already holds it.
+	lock := filepath.Join(t.TempDir(), "lock")
+	command := "mkdir " + lock + " || exit 9; sleep 0.05; rmdir " + lock
+	entries := []Entry{{Command: command}, {Command: command}, {Command: command}}
+
+	var buf bytes.Buffer
+	r := NewRunner(0, 0, "{filename}{n}", "", false)
+	r.Jobs = 3
+	if err := r.RunEntries(entries, &buf); err != nil {
+		t.Fatalf("RunEntries error: %v", err)
+	}
+	if n := strings.Count(buf.String(), "(exit 0)"); n != len(entries) {
+		t.Errorf("output = %q, want every command to exit 0", buf.String())
+	}
+}
+
+func TestRunEntries_Command(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	var buf bytes.Buffer
+	r := NewRunner(1, 1, "{filename} [{language}]{n}", "--{n}", true)
+	r.EOL = EOLLF
+	r.Symbol = "Ignored"
+	if err := r.RunEntries([]Entry{{Command: "printf 'a\\nb\\nc\\n'; exit 1"}}, &buf); err != nil {
+		t.Fatalf("RunEntries error: %v", err)
+	}
+
+	want := "$ printf 'a\\nb\\nc\\n'; exit 1 (exit 1) [text]\n1: a\n... (1 rows skipped)\n3: c\n--\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
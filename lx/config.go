@@ -1,5 +1,7 @@
 package lx
 
+import "time"
+
 // Options holds CLI-level configuration before effective values are derived.
 type Options struct {
 	Head  int
@@ -13,6 +15,47 @@ type Options struct {
 	PrefixDelimiter  string
 	PostfixDelimiter string
 	LineNumbers      bool
+	LineNumberFormat string
+
+	From   int
+	To     int
+	Symbol string
+
+	FromRegex string
+	ToRegex   string
+
+	Since string
+	Until string
+
+	KeepLines string
+	DropLines string
+
+	DedupeLines     bool
+	DedupeNormalize []string
+
+	NotebookOutputs bool
+	Tables          bool
+	SummarizeData   bool
+	DataSchema      bool
+	EmbedImages     bool
+
+	Languages map[string]string
+
+	Match   string
+	Context int
+
+	Jobs int
+
+	CommandTimeout time.Duration
+
+	KeepGoing  bool
+	SkipErrors bool
+
+	EOL string
+
+	Ellipsis string
+
+	MaxLineLength int
 }
 
 // Effective derives a fully configured Runner from the options, applying
@@ -61,11 +104,39 @@ func (o Options) Effective() Runner {
 		}
 	}
 
-	return NewRunner(
+	r := NewRunner(
 		effHead,
 		effTail,
 		o.PrefixDelimiter,
 		o.PostfixDelimiter,
 		o.LineNumbers,
 	)
+	r.From = o.From
+	r.To = o.To
+	r.Symbol = o.Symbol
+	r.FromRegex = o.FromRegex
+	r.ToRegex = o.ToRegex
+	r.Since = o.Since
+	r.Until = o.Until
+	r.KeepLines = o.KeepLines
+	r.DropLines = o.DropLines
+	r.DedupeLines = o.DedupeLines
+	r.DedupeNormalize = o.DedupeNormalize
+	r.NotebookOutputs = o.NotebookOutputs
+	r.Tables = o.Tables
+	r.SummarizeData = o.SummarizeData
+	r.DataSchema = o.DataSchema
+	r.EmbedImages = o.EmbedImages
+	r.Languages = o.Languages
+	r.Match = o.Match
+	r.Context = o.Context
+	r.Jobs = o.Jobs
+	r.CommandTimeout = o.CommandTimeout
+	r.KeepGoing = o.KeepGoing || o.SkipErrors
+	r.SkipErrors = o.SkipErrors
+	r.LineNumberFormat = o.LineNumberFormat
+	r.EOL = o.EOL
+	r.Ellipsis = o.Ellipsis
+	r.MaxLineLength = o.MaxLineLength
+	return r
 }
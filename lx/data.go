@@ -0,0 +1,418 @@
+package lx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits applied by --summarize-data.
+const (
+	summaryArrayItems   = 3   // array elements kept before "… N more items"
+	summaryStringLength = 120 // characters kept of long strings
+)
+
+// isDataFile reports whether path is a JSON or YAML document.
+func isDataFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// errNotSummarized marks documents that SummarizeData could not parse.
+var errNotSummarized = errors.New("not summarized")
+
+// summarizeData shortens a JSON or YAML document while keeping its
+// structure: long arrays keep their first elements followed by a
+// "… N more items" marker, and long strings are cut. With schema, the
+// structure inferred from the whole JSON document is appended as comments,
+// and the returned fence language becomes "jsonc".
+func summarizeData(data []byte, path string, schema bool) ([]byte, string, error) {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return summarizeJSON(data, schema)
+	}
+	return summarizeYAML(data), "", nil
+}
+
+// dataNode is a parsed JSON value. Object keys keep their document order.
+type dataNode struct {
+	kind   dataKind
+	keys   []string    // object keys
+	values []*dataNode // object values or array elements
+	scalar any         // string, json.Number, bool or nil
+}
+
+type dataKind int
+
+const (
+	kindNull dataKind = iota
+	kindBool
+	kindNumber
+	kindString
+	kindArray
+	kindObject
+)
+
+func summarizeJSON(data []byte, schema bool) ([]byte, string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var (
+		buf   bytes.Buffer
+		roots []*dataNode
+	)
+	for {
+		n, err := parseDataNode(dec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("parse JSON: %w", err)
+		}
+		writeDataNode(&buf, n, "")
+		buf.WriteString("\n")
+		roots = append(roots, n)
+	}
+
+	if !schema {
+		return buf.Bytes(), "", nil
+	}
+	buf.WriteString("\n// schema\n")
+	for _, n := range roots {
+		for _, line := range dataSchema(n, "$") {
+			buf.WriteString("// " + line + "\n")
+		}
+	}
+	return buf.Bytes(), "jsonc", nil
+}
+
+func parseDataNode(dec *json.Decoder) (*dataNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		n := &dataNode{kind: kindArray}
+		if t == '{' {
+			n.kind = kindObject
+		}
+		for dec.More() {
+			if n.kind == kindObject {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key.(string))
+			}
+			v, err := parseDataNode(dec)
+			if err != nil {
+				return nil, noEOF(err)
+			}
+			n.values = append(n.values, v)
+		}
+		// The closing delimiter.
+		if _, err := dec.Token(); err != nil {
+			return nil, noEOF(err)
+		}
+		return n, nil
+	case string:
+		return &dataNode{kind: kindString, scalar: t}, nil
+	case json.Number:
+		return &dataNode{kind: kindNumber, scalar: t}, nil
+	case bool:
+		return &dataNode{kind: kindBool, scalar: t}, nil
+	}
+	return &dataNode{kind: kindNull}, nil
+}
+
+// noEOF reports an end of input inside a value as unexpected.
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// writeDataNode writes n as indented JSON, shortening long arrays and
+// strings.
+func writeDataNode(buf *bytes.Buffer, n *dataNode, indent string) {
+	switch n.kind {
+	case kindObject, kindArray:
+		open, close := "[", "]"
+		if n.kind == kindObject {
+			open, close = "{", "}"
+		}
+		if len(n.values) == 0 {
+			buf.WriteString(open + close)
+			return
+		}
+
+		inner := indent + "  "
+		shown := n.values
+		if n.kind == kindArray && len(shown) > summaryArrayItems+1 {
+			shown = shown[:summaryArrayItems]
+		}
+		buf.WriteString(open + "\n")
+		for i, v := range shown {
+			buf.WriteString(inner)
+			if n.kind == kindObject {
+				buf.WriteString(jsonString(n.keys[i]) + ": ")
+			}
+			writeDataNode(buf, v, inner)
+			if i < len(n.values)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		if rest := len(n.values) - len(shown); rest > 0 {
+			buf.WriteString(inner + jsonString(fmt.Sprintf("… %s more items", formatThousands(rest))) + "\n")
+		}
+		buf.WriteString(indent + close)
+	case kindString:
+		buf.WriteString(jsonString(shortenString(n.scalar.(string))))
+	case kindNumber:
+		buf.WriteString(n.scalar.(json.Number).String())
+	case kindBool:
+		buf.WriteString(fmt.Sprint(n.scalar))
+	default:
+		buf.WriteString("null")
+	}
+}
+
+// jsonString encodes s as a JSON string without HTML escaping.
+func jsonString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// shortenString cuts s after summaryStringLength characters, noting how
+// many were cut.
+func shortenString(s string) string {
+	if utf8.RuneCountInString(s) <= summaryStringLength {
+		return s
+	}
+	cut, kept := 0, 0
+	for kept < summaryStringLength {
+		_, size := utf8.DecodeRuneInString(s[cut:])
+		cut += size
+		kept++
+	}
+	return s[:cut] + fmt.Sprintf("… (+%s chars)", formatThousands(utf8.RuneCountInString(s[cut:])))
+}
+
+// dataSchema describes the structure of n as one line per path, such as
+// "$.users[].id: integer". Fields of the objects in an array are merged
+// across all of its elements.
+func dataSchema(n *dataNode, path string) []string {
+	var lines []string
+	var walk func(nodes []*dataNode, path string)
+	walk = func(nodes []*dataNode, path string) {
+		// Array elements are already described by "array of".
+		if !strings.HasSuffix(path, "[]") {
+			lines = append(lines, path+": "+schemaType(nodes))
+		}
+
+		var (
+			keys   []string
+			fields = map[string][]*dataNode{}
+			items  []*dataNode
+		)
+		for _, n := range nodes {
+			for i, k := range n.keys {
+				if _, ok := fields[k]; !ok {
+					keys = append(keys, k)
+				}
+				fields[k] = append(fields[k], n.values[i])
+			}
+			if n.kind == kindArray {
+				items = append(items, n.values...)
+			}
+		}
+		for _, k := range keys {
+			walk(fields[k], path+"."+k)
+		}
+		if hasContainers(items) {
+			walk(items, path+"[]")
+		}
+	}
+	walk([]*dataNode{n}, path)
+	return lines
+}
+
+// schemaType names the type shared by nodes, or lists the types when they
+// differ, e.g. "string | null". Empty arrays fit any array type.
+func schemaType(nodes []*dataNode) string {
+	var types []string
+	for _, n := range nodes {
+		t := nodeType(n)
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	if slices.ContainsFunc(types, func(t string) bool { return strings.HasPrefix(t, "array of ") }) {
+		types = slices.DeleteFunc(types, func(t string) bool { return t == "array" })
+	}
+	return strings.Join(types, " | ")
+}
+
+func nodeType(n *dataNode) string {
+	switch n.kind {
+	case kindObject:
+		return "object"
+	case kindArray:
+		if len(n.values) == 0 {
+			return "array"
+		}
+		return "array of " + schemaType(n.values)
+	case kindString:
+		return "string"
+	case kindNumber:
+		if strings.ContainsAny(n.scalar.(json.Number).String(), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case kindBool:
+		return "boolean"
+	}
+	return "null"
+}
+
+func hasContainers(nodes []*dataNode) bool {
+	for _, n := range nodes {
+		if n.kind == kindObject || n.kind == kindArray {
+			return true
+		}
+	}
+	return false
+}
+
+// summarizeYAML shortens block sequences and long scalars of a YAML
+// document by indentation, without parsing it: sequence items after the
+// first few are replaced by a "# … N more items" comment, and long values
+// are cut. Block scalars (| and >) are copied unchanged.
+func summarizeYAML(data []byte) []byte {
+	var (
+		out         []byte
+		seqIndent   = -1 // indentation of the sequence being skipped
+		skipped     int
+		items       = map[int]int{} // items seen per sequence indentation
+		blockIndent = -1            // indentation of a block scalar's key
+	)
+
+	flush := func() {
+		if seqIndent >= 0 {
+			out = fmt.Appendf(out, "%s# … %s more items\n", strings.Repeat(" ", seqIndent), formatThousands(skipped))
+			seqIndent, skipped = -1, 0
+		}
+	}
+
+	for _, line := range splitLines(data) {
+		body := bytes.TrimRight(line, "\r\n")
+		trimmed := bytes.TrimLeft(body, " ")
+		indent := len(body) - len(trimmed)
+		blank := len(trimmed) == 0 || trimmed[0] == '#'
+
+		if blockIndent >= 0 {
+			if blank || indent > blockIndent {
+				out = append(out, line...)
+				continue
+			}
+			blockIndent = -1
+		}
+		if blank {
+			if seqIndent < 0 {
+				out = append(out, line...)
+			}
+			continue
+		}
+
+		item := bytes.HasPrefix(trimmed, []byte("- ")) || bytes.Equal(trimmed, []byte("-"))
+
+		// Counts of deeper sequences end with the enclosing item or key.
+		for i := range items {
+			if i > indent || i == indent && !item {
+				delete(items, i)
+			}
+		}
+
+		if seqIndent >= 0 {
+			if indent > seqIndent {
+				continue
+			}
+			if indent == seqIndent && item {
+				skipped++
+				continue
+			}
+			flush()
+		}
+
+		if item {
+			items[indent]++
+			if items[indent] > summaryArrayItems {
+				seqIndent, skipped = indent, 1
+				continue
+			}
+		}
+
+		if isBlockScalar(trimmed) {
+			blockIndent = indent
+		}
+		out = append(out, shortenYAMLValue(line)...)
+	}
+	flush()
+	return out
+}
+
+// isBlockScalar reports whether a YAML line starts a literal or folded
+// block scalar, such as "script: |".
+func isBlockScalar(trimmed []byte) bool {
+	i := bytes.LastIndexAny(trimmed, ":-")
+	if i < 0 {
+		return false
+	}
+	rest := bytes.TrimSpace(trimmed[i+1:])
+	return len(rest) > 0 && (rest[0] == '|' || rest[0] == '>') &&
+		len(bytes.Trim(rest[1:], "+-0123456789")) == 0
+}
+
+// shortenYAMLValue cuts the scalar value of a "key: value" or "- value" line
+// after summaryStringLength characters, keeping a closing quote.
+func shortenYAMLValue(line []byte) []byte {
+	body := bytes.TrimRight(line, "\r\n")
+	if utf8.RuneCount(body) <= summaryStringLength {
+		return line
+	}
+
+	start := 0
+	if i := bytes.Index(body, []byte(": ")); i >= 0 {
+		start = i + 2
+	} else if trimmed := bytes.TrimLeft(body, " "); bytes.HasPrefix(trimmed, []byte("- ")) {
+		start = len(body) - len(trimmed) + 2
+	}
+	value := string(body[start:])
+	if utf8.RuneCountInString(value) <= summaryStringLength {
+		return line
+	}
+
+	quote := ""
+	if q := value[0]; (q == '"' || q == '\'') && strings.HasSuffix(value, string(q)) {
+		quote = string(q)
+		value = value[1 : len(value)-1]
+	}
+	out := append([]byte(nil), body[:start]...)
+	out = append(out, quote+shortenString(value)+quote...)
+	return append(out, line[len(body):]...)
+}
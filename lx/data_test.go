@@ -0,0 +1,122 @@
+package lx
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeJSON(t *testing.T) {
+	doc := `{"z": 1, "a": [1, 2, 3, 4, 5, 6], "s": "` + strings.Repeat("é", summaryStringLength+5) +
+		`", "small": [1, 2, 3, 4], "o": {"x": null, "y": true}, "e": []}`
+
+	got, lang, err := summarizeJSON([]byte(doc), false)
+	if err != nil {
+		t.Fatalf("summarizeJSON error: %v", err)
+	}
+	want := "{\n" +
+		`  "z": 1,` + "\n" +
+		`  "a": [` + "\n" +
+		"    1,\n    2,\n    3,\n" +
+		`    "… 3 more items"` + "\n" +
+		"  ],\n" +
+		`  "s": "` + strings.Repeat("é", summaryStringLength) + `… (+5 chars)",` + "\n" +
+		`  "small": [` + "\n" +
+		"    1,\n    2,\n    3,\n    4\n" +
+		"  ],\n" +
+		`  "o": {` + "\n" +
+		`    "x": null,` + "\n" +
+		`    "y": true` + "\n" +
+		"  },\n" +
+		`  "e": []` + "\n" +
+		"}\n"
+	if string(got) != want || lang != "" {
+		t.Errorf("summarizeJSON = %q (%q), want %q", got, lang, want)
+	}
+	if !json.Valid(got) {
+		t.Errorf("summarizeJSON output is not valid JSON: %q", got)
+	}
+}
+
+func TestSummarizeJSON_Schema(t *testing.T) {
+	doc := `{"users": [{"id": 1, "tags": []}, {"id": 2, "tags": ["a"], "bio": null}, {"id": 3.5, "bio": "x"}]}`
+
+	got, lang, err := summarizeJSON([]byte(doc), true)
+	if err != nil {
+		t.Fatalf("summarizeJSON error: %v", err)
+	}
+	want := "// schema\n" +
+		"// $: object\n" +
+		"// $.users: array of object\n" +
+		"// $.users[].id: integer | number\n" +
+		"// $.users[].tags: array of string\n" +
+		"// $.users[].bio: null | string\n"
+	if !strings.HasSuffix(string(got), want) || lang != "jsonc" {
+		t.Errorf("summarizeJSON schema = %q (%q), want suffix %q", got, lang, want)
+	}
+}
+
+func TestSummarizeJSON_Invalid(t *testing.T) {
+	if _, _, err := summarizeJSON([]byte(`{"a": [1, 2`), false); err == nil {
+		t.Errorf("summarizeJSON on truncated JSON succeeded, want error")
+	}
+}
+
+func TestRunEntries_UnparsableDataShownAsIs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tsconfig.json")
+	doc := "{\n  // comment\n  \"strict\": true,\n}\n"
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	r := Options{SummarizeData: true}.Effective()
+	r.EOL = EOLLF
+	if err := r.RunEntries([]Entry{{Path: path}}, &buf); err != nil {
+		t.Fatalf("RunEntries error: %v", err)
+	}
+	if want := path + " (4 rows, not summarized)"; !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("output = %q, want header %q", buf.String(), want)
+	}
+	if !strings.Contains(buf.String(), doc) {
+		t.Errorf("output = %q, want the document unchanged", buf.String())
+	}
+}
+
+func TestSummarizeYAML(t *testing.T) {
+	doc := "items:\n" +
+		"  - id: 1\n" +
+		"  - id: 2\n" +
+		"    tags:\n" +
+		"      - a\n" +
+		"  - id: 3\n" +
+		"  - id: 4\n" +
+		"    name: four\n" +
+		"  - id: 5\n" +
+		"script: |\n" +
+		"  - one\n" +
+		"  - two\n" +
+		"  - three\n" +
+		"  - four\n" +
+		"long: '" + strings.Repeat("x", summaryStringLength+10) + "'\n"
+
+	want := "items:\n" +
+		"  - id: 1\n" +
+		"  - id: 2\n" +
+		"    tags:\n" +
+		"      - a\n" +
+		"  - id: 3\n" +
+		"  # … 2 more items\n" +
+		"script: |\n" +
+		"  - one\n" +
+		"  - two\n" +
+		"  - three\n" +
+		"  - four\n" +
+		"long: '" + strings.Repeat("x", summaryStringLength) + "… (+10 chars)'\n"
+	if got := string(summarizeYAML([]byte(doc))); got != want {
+		t.Errorf("summarizeYAML = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,202 @@
+package lx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding names reported through the {encoding} placeholder.
+const (
+	encUTF8        = "utf-8"
+	encUTF8BOM     = "utf-8-bom"
+	encUTF16LE     = "utf-16le"
+	encUTF16BE     = "utf-16be"
+	encWindows1252 = "windows-1252"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// encodingSniffSize is how much of a file is inspected to detect UTF-16.
+const encodingSniffSize = 4096
+
+// sniffEncoding detects encodings that are recognizable from the start of a
+// file: UTF-8 with a BOM and UTF-16 with or without one. It returns "" when
+// the sample doesn't identify one, in which case the content is either UTF-8
+// or, if it turns out not to be valid UTF-8, Windows-1252.
+func sniffEncoding(sample []byte) string {
+	switch {
+	case bytes.HasPrefix(sample, bomUTF8):
+		return encUTF8BOM
+	case bytes.HasPrefix(sample, bomUTF16LE):
+		return encUTF16LE
+	case bytes.HasPrefix(sample, bomUTF16BE):
+		return encUTF16BE
+	}
+
+	// Mostly-ASCII UTF-16 text has a NUL in every other byte: the odd ones
+	// for little endian, the even ones for big endian.
+	pairs := len(sample) / 2
+	if pairs < 2 {
+		return ""
+	}
+	var evenNUL, oddNUL int
+	for i := 0; i+1 < len(sample); i += 2 {
+		if sample[i] == 0 {
+			evenNUL++
+		}
+		if sample[i+1] == 0 {
+			oddNUL++
+		}
+	}
+	switch {
+	case oddNUL*10 >= pairs*3 && evenNUL*10 < pairs:
+		return encUTF16LE
+	case evenNUL*10 >= pairs*3 && oddNUL*10 < pairs:
+		return encUTF16BE
+	}
+	return ""
+}
+
+// decodeText detects the encoding of data and returns it transcoded to UTF-8
+// along with the detected encoding name.
+func decodeText(data []byte) ([]byte, string) {
+	switch enc := sniffEncoding(data[:min(len(data), encodingSniffSize)]); enc {
+	case encUTF8BOM:
+		return data[len(bomUTF8):], enc
+	case encUTF16LE:
+		return decodeUTF16(bytes.TrimPrefix(data, bomUTF16LE), binary.LittleEndian), enc
+	case encUTF16BE:
+		return decodeUTF16(bytes.TrimPrefix(data, bomUTF16BE), binary.BigEndian), enc
+	}
+
+	if utf8.Valid(data) {
+		return data, encUTF8
+	}
+	return decodeWindows1252(data), encWindows1252
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) []byte {
+	var out bytes.Buffer
+	out.Grow(len(data) / 2)
+	// Reading from memory can't fail.
+	_ = copyUTF16(&out, bytes.NewReader(data), order)
+	return out.Bytes()
+}
+
+// copyUTF16 transcodes UTF-16 text read from src to UTF-8 written to dst, a
+// code unit at a time, so that large files need not be held in memory.
+// Unpaired surrogates become U+FFFD, as with utf16.Decode, and a trailing odd
+// byte can't form a code unit and is dropped.
+func copyUTF16(dst io.Writer, src io.Reader, order binary.ByteOrder) error {
+	br := bufio.NewReaderSize(src, streamChunkSize)
+	bw := bufio.NewWriterSize(dst, streamChunkSize)
+	var (
+		unit [2]byte
+		enc  [utf8.UTFMax]byte
+		high rune = -1 // a high surrogate waiting for its low half
+	)
+	emit := func(r rune) {
+		bw.Write(utf8.AppendRune(enc[:0], r))
+	}
+
+	for {
+		if _, err := io.ReadFull(br, unit[:]); err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				return err
+			}
+			break
+		}
+		u := rune(order.Uint16(unit[:]))
+		if high >= 0 {
+			if u >= 0xDC00 && u < 0xE000 {
+				emit(utf16.DecodeRune(high, u))
+				high = -1
+				continue
+			}
+			emit(utf8.RuneError)
+			high = -1
+		}
+		switch {
+		case u >= 0xD800 && u < 0xDC00:
+			high = u
+		case utf16.IsSurrogate(u):
+			emit(utf8.RuneError)
+		default:
+			emit(u)
+		}
+	}
+	if high >= 0 {
+		emit(utf8.RuneError)
+	}
+	return bw.Flush()
+}
+
+// windows1252 maps bytes 0x80-0x9F to the characters Windows-1252 assigns
+// them. The five bytes it leaves undefined, and all bytes from 0xA0 up, map to
+// the code point of the same value, as in Latin-1.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\u008D', 'Ž', '\u008F',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '\u009D', 'ž', 'Ÿ',
+}
+
+func decodeWindows1252(data []byte) []byte {
+	out := make([]byte, 0, len(data)+len(data)/8)
+	for _, b := range data {
+		switch {
+		case b < 0x80:
+			out = append(out, b)
+		case b < 0xA0:
+			out = utf8.AppendRune(out, windows1252[b-0x80])
+		default:
+			out = utf8.AppendRune(out, rune(b))
+		}
+	}
+	return out
+}
+
+// utf8Validator checks that a byte stream fed in arbitrary chunks is valid
+// UTF-8, carrying incomplete runes over chunk boundaries.
+type utf8Validator struct {
+	carry   []byte
+	invalid bool
+}
+
+func (v *utf8Validator) write(p []byte) {
+	if v.invalid {
+		return
+	}
+	if len(v.carry) > 0 {
+		p = append(v.carry, p...)
+		v.carry = nil
+	}
+
+	// Hold back a rune that continues in the next chunk.
+	cut := len(p)
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if !utf8.FullRune(p[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+
+	if !utf8.Valid(p[:cut]) {
+		v.invalid = true
+		return
+	}
+	v.carry = append([]byte(nil), p[cut:]...)
+}
+
+func (v *utf8Validator) valid() bool {
+	return !v.invalid && len(v.carry) == 0
+}
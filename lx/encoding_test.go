@@ -0,0 +1,129 @@
+package lx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func utf16Bytes(s string, bigEndian, bom bool) []byte {
+	var out []byte
+	units := utf16.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xFEFF}, units...)
+	}
+	for _, u := range units {
+		if bigEndian {
+			out = append(out, byte(u>>8), byte(u))
+		} else {
+			out = append(out, byte(u), byte(u>>8))
+		}
+	}
+	return out
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []byte
+		want    string
+		wantEnc string
+	}{
+		{"ascii", []byte("a,b\n"), "a,b\n", encUTF8},
+		{"utf-8", []byte("naïve\n"), "naïve\n", encUTF8},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, "id\n"...), "id\n", encUTF8BOM},
+		{"utf-16le bom", utf16Bytes("héllo\r\nworld\r\n", false, true), "héllo\r\nworld\r\n", encUTF16LE},
+		{"utf-16be bom", utf16Bytes("héllo\n", true, true), "héllo\n", encUTF16BE},
+		{"utf-16le no bom", utf16Bytes("2024-01-01 started\n", false, false), "2024-01-01 started\n", encUTF16LE},
+		{"utf-16be no bom", utf16Bytes("2024-01-01 started\n", true, false), "2024-01-01 started\n", encUTF16BE},
+		{"latin-1", []byte("caf\xe9;\xa35\n"), "café;£5\n", encWindows1252},
+		{"windows-1252", []byte("\x93quoted\x94 \x80 10\n"), "“quoted” € 10\n", encWindows1252},
+	}
+
+	for _, tt := range tests {
+		got, enc := decodeText(tt.input)
+		if string(got) != tt.want || enc != tt.wantEnc {
+			t.Errorf("%s: decodeText = %q (%s), want %q (%s)", tt.name, got, enc, tt.want, tt.wantEnc)
+		}
+	}
+}
+
+func TestUTF8Validator_SplitRunes(t *testing.T) {
+	input := []byte("ééé€€€")
+	for size := 1; size <= len(input); size++ {
+		var v utf8Validator
+		for i := 0; i < len(input); i += size {
+			v.write(input[i:min(i+size, len(input))])
+		}
+		if !v.valid() {
+			t.Errorf("chunk size %d: valid UTF-8 reported invalid", size)
+		}
+	}
+
+	var v utf8Validator
+	v.write([]byte("caf\xe9 au lait"))
+	if v.valid() {
+		t.Errorf("Latin-1 input reported as valid UTF-8")
+	}
+
+	v = utf8Validator{}
+	v.write([]byte("trailing \xe2\x82"))
+	if v.valid() {
+		t.Errorf("truncated rune at end reported as valid UTF-8")
+	}
+}
+
+func TestRunner_TranscodesAndReportsEncoding(t *testing.T) {
+	dir := t.TempDir()
+	utf16Path := filepath.Join(dir, "export.csv")
+	latinPath := filepath.Join(dir, "legacy.log")
+	if err := os.WriteFile(utf16Path, utf16Bytes("name\r\nJosé\r\nZoë\r\n", false, true), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(latinPath, []byte("a\nb\ncaf\xe9\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tail := range []int{0, 1} {
+		var buf bytes.Buffer
+		r := NewRunner(0, tail, "[{encoding}]{n}", "", false)
+		r.EOL = EOLPreserve
+		if err := r.Run([]string{utf16Path, latinPath}, &buf); err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+
+		out := buf.String()
+		if strings.ContainsRune(out, 0) {
+			t.Errorf("tail %d: output contains NUL bytes: %q", tail, out)
+		}
+		for _, want := range []string{"[utf-16le]\n", "Zoë\r\n", "[windows-1252]\n", "café\n"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("tail %d: output missing %q:\n%q", tail, want, out)
+			}
+		}
+	}
+}
+
+func TestDecodeUTF16_UnpairedSurrogates(t *testing.T) {
+	inputs := [][]uint16{
+		{'a', 0xD83D, 0xDE00, 'b'},
+		{0xD83D, 'a'},
+		{0xDE00, 'a'},
+		{'a', 0xD83D},
+		{0xD83D, 0xD83D, 0xDE00},
+	}
+	for _, units := range inputs {
+		var data []byte
+		for _, u := range units {
+			data = append(data, byte(u), byte(u>>8))
+		}
+		want := string(utf16.Decode(units))
+		if got := string(decodeUTF16(append(data, 'x'), binary.LittleEndian)); got != want {
+			t.Errorf("decodeUTF16(%x) = %q, want %q", units, got, want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package lx
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Line ending modes for Runner.EOL. "preserve" leaves file content untouched
+// and uses native line endings for delimiters; the other modes apply the same
+// line ending to both.
+const (
+	EOLNative   = "native"
+	EOLLF       = "lf"
+	EOLCRLF     = "crlf"
+	EOLPreserve = "preserve"
+)
+
+func validateEOL(mode string) error {
+	switch mode {
+	case "", EOLNative, EOLLF, EOLCRLF, EOLPreserve:
+		return nil
+	}
+	return fmt.Errorf("invalid --eol %q: use lf, crlf, native or preserve", mode)
+}
+
+// newline returns the line ending used for delimiters and, unless EOL is
+// "preserve", for file content.
+func (r Runner) newline() string {
+	switch r.EOL {
+	case EOLLF:
+		return "\n"
+	case EOLCRLF:
+		return "\r\n"
+	}
+	return nl
+}
+
+// normalizeEOL replaces every line ending in data ("\r\n", "\n" or a lone
+// "\r") with eol.
+func normalizeEOL(data []byte, eol string) []byte {
+	if bytes.IndexByte(data, '\r') < 0 && eol == "\n" {
+		return data
+	}
+
+	out := make([]byte, 0, len(data)+len(data)/32)
+	for len(data) > 0 {
+		i := bytes.IndexAny(data, "\r\n")
+		if i < 0 {
+			out = append(out, data...)
+			break
+		}
+		out = append(out, data[:i]...)
+		out = append(out, eol...)
+		if data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+			i++
+		}
+		data = data[i+1:]
+	}
+	return out
+}
+
+// endingCounter tallies the line endings of data fed in arbitrary chunks.
+type endingCounter struct {
+	lf, crlf, cr int
+	pendingCR    bool
+}
+
+func (c *endingCounter) write(p []byte) {
+	for len(p) > 0 {
+		if c.pendingCR {
+			c.pendingCR = false
+			if p[0] == '\n' {
+				c.crlf++
+				p = p[1:]
+				continue
+			}
+			c.cr++
+		}
+
+		i := bytes.IndexAny(p, "\r\n")
+		if i < 0 {
+			return
+		}
+		if p[i] == '\n' {
+			c.lf++
+		} else {
+			// Whether this is CRLF depends on the next byte.
+			c.pendingCR = true
+		}
+		p = p[i+1:]
+	}
+}
+
+// finish accounts for a carriage return at the very end of the data.
+func (c *endingCounter) finish() {
+	if c.pendingCR {
+		c.cr++
+		c.pendingCR = false
+	}
+}
+
+// kind classifies the counted endings as "lf", "crlf", "cr", "mixed", or ""
+// when there were none.
+func (c endingCounter) kind() string {
+	kinds, name := 0, ""
+	for _, k := range []struct {
+		n    int
+		name string
+	}{{c.lf, "lf"}, {c.crlf, "crlf"}, {c.cr, "cr"}} {
+		if k.n > 0 {
+			kinds++
+			name = k.name
+		}
+	}
+	if kinds > 1 {
+		return "mixed"
+	}
+	return name
+}
+
+// lineEndings classifies the line endings used in data.
+func lineEndings(data []byte) string {
+	var c endingCounter
+	c.write(data)
+	c.finish()
+	return c.kind()
+}
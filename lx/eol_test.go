@@ -0,0 +1,119 @@
+package lx
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeEOL(t *testing.T) {
+	tests := []struct {
+		input string
+		eol   string
+		want  string
+	}{
+		{"a\nb\n", "\n", "a\nb\n"},
+		{"a\r\nb\nc\rd", "\n", "a\nb\nc\nd"},
+		{"a\r\nb\nc\rd\n", "\r\n", "a\r\nb\r\nc\r\nd\r\n"},
+		{"\r\r\n\n", "\n", "\n\n\n"},
+		{"no ending", "\r\n", "no ending"},
+	}
+
+	for _, tt := range tests {
+		got := normalizeEOL([]byte(tt.input), tt.eol)
+		if string(got) != tt.want {
+			t.Errorf("normalizeEOL(%q, %q) = %q, want %q", tt.input, tt.eol, got, tt.want)
+		}
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	tests := map[string]string{
+		"":              "",
+		"one":           "",
+		"a\nb\n":        "lf",
+		"a\r\nb\r\n":    "crlf",
+		"a\rb\r":        "cr",
+		"a\r\nb\nc":     "mixed",
+		"a\r\nb\r\nc\r": "mixed",
+	}
+	for input, want := range tests {
+		if got := lineEndings([]byte(input)); got != want {
+			t.Errorf("lineEndings(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestEndingCounter_CRLFAcrossChunks(t *testing.T) {
+	var c endingCounter
+	c.write([]byte("a\r"))
+	c.write([]byte("\nb\r"))
+	c.write([]byte("\n"))
+	c.finish()
+	if c.kind() != "crlf" {
+		t.Errorf("kind = %q, want crlf (counts %+v)", c.kind(), c)
+	}
+}
+
+func TestValidateEOL(t *testing.T) {
+	for _, ok := range []string{"", "lf", "crlf", "native", "preserve"} {
+		if err := validateEOL(ok); err != nil {
+			t.Errorf("validateEOL(%q) = %v, want nil", ok, err)
+		}
+	}
+	if err := validateEOL("unix"); err == nil {
+		t.Errorf("validateEOL(%q) = nil, want error", "unix")
+	}
+}
+
+func TestRunner_NormalizesMixedLineEndings(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mixed.txt")
+	if err := os.WriteFile(path, []byte("a\r\nb\nc\rd\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		eol  string
+		tail int
+		want string
+	}{
+		{EOLLF, 0, "4 mixed\na\nb\nc\nd\n--\n"},
+		{EOLCRLF, 0, "4 mixed\r\na\r\nb\r\nc\r\nd\r\n--\r\n"},
+		{EOLLF, 2, "4 mixed\nc\nd\n--\n"},
+		{EOLPreserve, 0, "4 mixed" + nl + "a\r\nb\nc\rd\r\n--" + nl},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		r := NewRunner(0, tt.tail, "{row_count} {line_endings}{n}", "--{n}", false)
+		r.EOL = tt.eol
+		if err := r.Run([]string{path}, &buf); err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("eol %s tail %d: output = %q, want %q", tt.eol, tt.tail, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestRunner_StreamedCRLFReportsEndings(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "win.log")
+	if err := os.WriteFile(path, []byte("1\r\n2\r\n3\r\n4\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	r := NewRunner(1, 1, "{line_endings}{n}", "", true)
+	r.EOL = EOLLF
+	if err := r.Run([]string{path}, &buf); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+
+	want := "crlf\n1: 1\n... (2 rows skipped)\n4: 4\n```\n\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
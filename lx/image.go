@@ -0,0 +1,101 @@
+package lx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"path/filepath"
+	"strings"
+)
+
+// imageTypes maps image extensions to their MIME types. SVG is text and is
+// shown as XML unless it is embedded.
+var imageTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".svg":  "image/svg+xml",
+}
+
+// imageType returns the MIME type of the image at path, or "" if it isn't
+// one handled as an image: raster images always are, and SVG only when
+// embedding.
+func imageType(path string, embed bool) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".svg" && !embed {
+		return ""
+	}
+	return imageTypes[ext]
+}
+
+// renderImage returns the rows shown for an image: a placeholder giving its
+// format, dimensions and size, or with embed a data URI of its content.
+// Neither is a line of the file, so both are rows without a number.
+func renderImage(data []byte, mime string, embed bool) ([]row, error) {
+	if embed {
+		uri := "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data)
+		return []row{{text: []byte(uri + "\n")}}, nil
+	}
+
+	var (
+		cfg    image.Config
+		format string
+		err    error
+	)
+	if mime == "image/webp" {
+		cfg, err = webpConfig(data)
+		format = "webp"
+	} else {
+		cfg, format, err = image.DecodeConfig(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse image: %w", err)
+	}
+	text := fmt.Sprintf("[image: %s, %d×%d, %s bytes]\n", format, cfg.Width, cfg.Height, formatThousands(len(data)))
+	return []row{{text: []byte(text)}}, nil
+}
+
+var errWebP = errors.New("webp: invalid format")
+
+// webpConfig reads the dimensions of a WebP image from the header of its
+// first chunk, which is lossy (VP8), lossless (VP8L) or extended (VP8X).
+func webpConfig(data []byte) (image.Config, error) {
+	if len(data) < 30 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return image.Config{}, errWebP
+	}
+	chunk := data[20:]
+
+	var w, h int
+	switch string(data[12:16]) {
+	case "VP8 ":
+		// A 3-byte frame tag and a start code precede the 14-bit sizes.
+		if !bytes.Equal(chunk[3:6], []byte{0x9d, 0x01, 0x2a}) {
+			return image.Config{}, errWebP
+		}
+		w = int(binary.LittleEndian.Uint16(chunk[6:8]) & 0x3fff)
+		h = int(binary.LittleEndian.Uint16(chunk[8:10]) & 0x3fff)
+	case "VP8L":
+		// A signature byte precedes the 14-bit sizes minus one.
+		if chunk[0] != 0x2f {
+			return image.Config{}, errWebP
+		}
+		bits := binary.LittleEndian.Uint32(chunk[1:5])
+		w = int(bits&0x3fff) + 1
+		h = int(bits>>14&0x3fff) + 1
+	case "VP8X":
+		// Flags and reserved bytes precede the 24-bit sizes minus one.
+		w = int(uint32(chunk[4])|uint32(chunk[5])<<8|uint32(chunk[6])<<16) + 1
+		h = int(uint32(chunk[7])|uint32(chunk[8])<<8|uint32(chunk[9])<<16) + 1
+	default:
+		return image.Config{}, errWebP
+	}
+	return image.Config{Width: w, Height: h}, nil
+}
@@ -0,0 +1,83 @@
+package lx
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestRenderImage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 3))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	rows, err := renderImage(data, "image/png", false)
+	if err != nil {
+		t.Fatalf("renderImage error: %v", err)
+	}
+	want := "[image: png, 4×3, " + formatThousands(len(data)) + " bytes]\n"
+	if len(rows) != 1 || string(rows[0].text) != want || rows[0].num != 0 {
+		t.Errorf("renderImage = %+v, want one unnumbered row %q", rows, want)
+	}
+
+	rows, err = renderImage([]byte("<svg/>"), "image/svg+xml", true)
+	if err != nil {
+		t.Fatalf("renderImage embed error: %v", err)
+	}
+	if want := "data:image/svg+xml;base64,PHN2Zy8+\n"; string(rows[0].text) != want {
+		t.Errorf("renderImage embed = %q, want %q", rows[0].text, want)
+	}
+
+	if _, err := renderImage([]byte("not a png"), "image/png", false); err == nil {
+		t.Errorf("renderImage on invalid data succeeded, want error")
+	}
+}
+
+func TestWebPConfig(t *testing.T) {
+	header := func(chunk string, payload ...byte) []byte {
+		data := []byte("RIFF\x00\x00\x00\x00WEBP" + chunk + "\x00\x00\x00\x00")
+		return append(data, append(payload, make([]byte, 16)...)...)
+	}
+	tests := []struct {
+		name string
+		data []byte
+		w, h int
+	}{
+		// 640×480 after a key frame tag and the start code.
+		{"lossy", header("VP8 ", 0, 0, 0, 0x9d, 0x01, 0x2a, 0x80, 0x02, 0xe0, 0x01), 640, 480},
+		// 14-bit width-1 = 99 and height-1 = 49 after the signature.
+		{"lossless", header("VP8L", 0x2f, 0x63, 0x40, 0x0c, 0x00), 100, 50},
+		// 24-bit width-1 = 1999 and height-1 = 999 after the flags.
+		{"extended", header("VP8X", 0, 0, 0, 0, 0xcf, 0x07, 0x00, 0xe7, 0x03, 0x00), 2000, 1000},
+	}
+
+	for _, tt := range tests {
+		cfg, err := webpConfig(tt.data)
+		if err != nil {
+			t.Errorf("%s: webpConfig error: %v", tt.name, err)
+			continue
+		}
+		if cfg.Width != tt.w || cfg.Height != tt.h {
+			t.Errorf("%s: webpConfig = %d×%d, want %d×%d", tt.name, cfg.Width, cfg.Height, tt.w, tt.h)
+		}
+	}
+
+	if _, err := webpConfig([]byte("RIFF\x00\x00\x00\x00WAVEfmt ")); err == nil {
+		t.Errorf("webpConfig on a non-WebP file succeeded, want error")
+	}
+}
+
+func TestImageType(t *testing.T) {
+	if got := imageType("photo.JPG", false); got != "image/jpeg" {
+		t.Errorf("imageType(photo.JPG) = %q, want image/jpeg", got)
+	}
+	if got := imageType("icon.svg", false); got != "" {
+		t.Errorf("imageType(icon.svg) = %q, want SVG shown as text", got)
+	}
+	if got := imageType("icon.svg", true); got != "image/svg+xml" {
+		t.Errorf("imageType(icon.svg, embed) = %q, want image/svg+xml", got)
+	}
+}
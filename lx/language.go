@@ -1,56 +1,275 @@
 package lx
 
 import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
-var extToLang = map[string]string{
-	".go":   "go",
-	".py":   "python",
-	".js":   "javascript",
-	".jsx":  "jsx",
-	".ts":   "typescript",
-	".tsx":  "tsx",
-	".rs":   "rust",
-	".java": "java",
+// languageTable is the built-in table of fence languages by extension and
+// file name; see parseLanguageTable for its format.
+//
+//go:embed languages.txt
+var languageTable []byte
 
-	".c": "c",
-	".h": "c",
+// extToLang and filenameToLang map lowercased extensions and file names to
+// their fence language.
+var extToLang, filenameToLang = mustParseLanguageTable(languageTable)
 
-	".cc":  "cpp",
-	".cpp": "cpp",
-	".cxx": "cpp",
-	".hpp": "cpp",
-	".hh":  "cpp",
-	".hxx": "cpp",
+// parseLanguageTable reads lines naming a fence language followed by the
+// extensions (starting with a dot) and file names it covers, with '#'
+// comments. Keys are lowercased, except for names starting with '=', which
+// are kept as written and matched exactly. Keys may appear only once.
+func parseLanguageTable(data []byte) (exts, names map[string]string, err error) {
+	exts, names = map[string]string{}, map[string]string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) == 1 {
+			return nil, nil, fmt.Errorf("line %d: language %q has no extensions or file names", i+1, fields[0])
+		}
 
-	".sh":   "bash",
-	".bash": "bash",
-	".zsh":  "zsh",
+		lang := fields[0]
+		for _, key := range fields[1:] {
+			if exact, ok := strings.CutPrefix(key, "="); ok {
+				key = exact
+			} else {
+				key = strings.ToLower(key)
+			}
+			table := names
+			if strings.HasPrefix(key, ".") {
+				table = exts
+			}
+			if prev, ok := table[key]; ok {
+				return nil, nil, fmt.Errorf("line %d: %q is already mapped to %s", i+1, key, prev)
+			}
+			table[key] = lang
+		}
+	}
+	return exts, names, nil
+}
 
-	".rb":  "ruby",
-	".php": "php",
+func mustParseLanguageTable(data []byte) (exts, names map[string]string) {
+	exts, names, err := parseLanguageTable(data)
+	if err != nil {
+		panic("languages.txt: " + err.Error())
+	}
+	return exts, names
+}
 
-	".html": "html",
-	".htm":  "html",
-	".css":  "css",
+// interpreterToLang maps shebang interpreters, without version suffixes, to
+// their language.
+var interpreterToLang = map[string]string{
+	"sh":         "bash",
+	"bash":       "bash",
+	"dash":       "bash",
+	"ksh":        "bash",
+	"zsh":        "zsh",
+	"fish":       "fish",
+	"python":     "python",
+	"pypy":       "python",
+	"node":       "javascript",
+	"nodejs":     "javascript",
+	"deno":       "typescript",
+	"ts-node":    "typescript",
+	"ruby":       "ruby",
+	"perl":       "perl",
+	"php":        "php",
+	"lua":        "lua",
+	"luajit":     "lua",
+	"rscript":    "r",
+	"awk":        "awk",
+	"gawk":       "awk",
+	"tclsh":      "tcl",
+	"pwsh":       "powershell",
+	"make":       "makefile",
+	"groovy":     "groovy",
+	"elixir":     "elixir",
+	"escript":    "erlang",
+	"runhaskell": "haskell",
+	"julia":      "julia",
+	"swift":      "swift",
+}
 
-	".json": "json",
+// languageAliases maps the names vim and emacs modelines use to the fence
+// languages used elsewhere.
+var languageAliases = map[string]string{
+	"sh":           "bash",
+	"shell":        "bash",
+	"shell-script": "bash",
+	"c++":          "cpp",
+	"cs":           "csharp",
+	"js":           "javascript",
+	"ts":           "typescript",
+	"py":           "python",
+	"rb":           "ruby",
+	"make":         "makefile",
+	"emacs-lisp":   "elisp",
+	"golang":       "go",
+	"yml":          "yaml",
+	"md":           "markdown",
+}
 
-	".yml":  "yaml",
-	".yaml": "yaml",
+// modelineLines is how many lines at each end of a file are searched for a
+// modeline, as vim does by default.
+const modelineLines = 5
 
-	".toml": "toml",
+var (
+	// vimModeline matches "vim: set ft=python:" and "vi: filetype=sh".
+	vimModeline = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex)(?:[<=>]?\d+)?:.*?(?:^|[\s:])(?:ft|filetype|syntax|syn)=([\w+.-]+)`)
+	// emacsModeline matches "-*- mode: python -*-" and "-*- python -*-".
+	emacsModeline = regexp.MustCompile(`-\*-\s*(.*?)\s*-\*-`)
+	emacsMode     = regexp.MustCompile(`(?i)(?:^|;)\s*mode:\s*([\w+.-]+)`)
+)
 
-	".md":       "markdown",
-	".markdown": "markdown",
+// languageFromPath returns a markdown language identifier derived from the
+// file name, or else from its extension.
+func languageFromPath(path string) string {
+	if lang := languageFromName(path, nil); lang != "" {
+		return lang
+	}
+	return languageFromExt(path, nil)
+}
 
-	".txt": "text",
+// languageFromName returns the language of a well-known file name such as
+// Makefile or Dockerfile.dev. Names in custom, lowercased, take precedence.
+func languageFromName(path string, custom map[string]string) string {
+	name := filepath.Base(path)
+	base := strings.ToLower(name)
+	if lang := custom[base]; lang != "" {
+		return lang
+	}
+	if strings.HasPrefix(base, "dockerfile.") || strings.HasSuffix(base, ".dockerfile") {
+		return "dockerfile"
+	}
+	// Names matched exactly, such as BUILD, are stored as written.
+	if lang := filenameToLang[name]; lang != "" {
+		return lang
+	}
+	return filenameToLang[base]
 }
 
-// languageFromPath returns a markdown language identifier derived from the file extension.
-func languageFromPath(path string) string {
+// languageFromExt returns the language of the file extension. Extensions in
+// custom, lowercased, take precedence.
+func languageFromExt(path string, custom map[string]string) string {
 	ext := strings.ToLower(filepath.Ext(path))
+	if lang := custom[ext]; lang != "" {
+		return lang
+	}
 	return extToLang[ext]
 }
+
+// detectLanguage returns the fence language of a file from, in order of
+// confidence: a vim or emacs modeline, a well-known file name, a shebang
+// and the extension. head and tail are the start and end of the file, and
+// may be the same slice. custom maps extensions and file names to languages
+// ahead of the built-in tables.
+func detectLanguage(path string, head, tail []byte, custom map[string]string) string {
+	if lang := modelineLanguage(head, tail); lang != "" {
+		return lang
+	}
+	if lang := languageFromName(path, custom); lang != "" {
+		return lang
+	}
+	if lang := shebangLanguage(head); lang != "" {
+		return lang
+	}
+	return languageFromExt(path, custom)
+}
+
+// languageSuffix matches the language given to a path as "path::lang".
+var languageSuffix = regexp.MustCompile(`^(.+)::([\w+#.-]+)$`)
+
+// splitLanguage splits a "path::lang" argument into its path and language.
+// Other arguments, including existing files whose names contain "::", are
+// returned as is with an empty language.
+func splitLanguage(arg string) (path, lang string) {
+	m := languageSuffix.FindStringSubmatch(arg)
+	if m == nil {
+		return arg, ""
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return arg, ""
+	}
+	return m[1], m[2]
+}
+
+// shebangLanguage returns the language of the interpreter named by a "#!"
+// first line, looking through env and its options.
+func shebangLanguage(head []byte) string {
+	if !bytes.HasPrefix(head, []byte("#!")) {
+		return ""
+	}
+	line, _, _ := bytes.Cut(head[2:], []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interp := filepath.Base(fields[0])
+	if interp == "env" {
+		interp = ""
+		for _, f := range fields[1:] {
+			// Options such as -S and variable assignments come first.
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				interp = filepath.Base(f)
+				break
+			}
+		}
+	}
+	// python3.12 and similar name the same language.
+	interp = strings.TrimRight(strings.ToLower(interp), "0123456789.")
+	return interpreterToLang[interp]
+}
+
+// modelineLanguage returns the language set by a vim modeline in the first
+// or last lines, or by an emacs modeline on the first line (the second,
+// after a shebang).
+func modelineLanguage(head, tail []byte) string {
+	lines := splitLines(head)
+	first := lines[:min(len(lines), 2)]
+	if len(first) == 2 && !bytes.HasPrefix(first[0], []byte("#!")) {
+		first = first[:1]
+	}
+	for _, line := range first {
+		m := emacsModeline.FindSubmatch(line)
+		if m == nil {
+			continue
+		}
+		mode := string(m[1])
+		if strings.Contains(mode, ":") {
+			mm := emacsMode.FindStringSubmatch(mode)
+			if mm == nil {
+				continue
+			}
+			mode = mm[1]
+		}
+		return normalizeLanguage(mode)
+	}
+
+	candidates := lines[:min(len(lines), modelineLines)]
+	last := splitLines(tail)
+	candidates = append(candidates, last[max(len(last)-modelineLines, 0):]...)
+	for _, line := range candidates {
+		if m := vimModeline.FindSubmatch(line); m != nil {
+			return normalizeLanguage(string(m[1]))
+		}
+	}
+	return ""
+}
+
+// normalizeLanguage maps a modeline language name to a fence language.
+func normalizeLanguage(name string) string {
+	name = strings.TrimSuffix(strings.ToLower(name), "-mode")
+	if alias, ok := languageAliases[name]; ok {
+		return alias
+	}
+	return name
+}
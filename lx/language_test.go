@@ -1,6 +1,10 @@
 package lx
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestLanguageFromPath(t *testing.T) {
 	tests := []struct {
@@ -168,6 +172,36 @@ func TestLanguageFromPath(t *testing.T) {
 			path: ".gitignore",
 			want: "",
 		},
+		{
+			name: "dockerfile",
+			path: "build/Dockerfile",
+			want: "dockerfile",
+		},
+		{
+			name: "dockerfile variant",
+			path: "Dockerfile.dev",
+			want: "dockerfile",
+		},
+		{
+			name: "makefile",
+			path: "Makefile",
+			want: "makefile",
+		},
+		{
+			name: "cmake lists",
+			path: "CMakeLists.txt",
+			want: "cmake",
+		},
+		{
+			name: "go module",
+			path: "go.mod",
+			want: "go",
+		},
+		{
+			name: "jenkinsfile",
+			path: "ci/Jenkinsfile",
+			want: "groovy",
+		},
 		{
 			name: "unknown extension",
 			path: "file.unknown",
@@ -183,3 +217,143 @@ func TestLanguageFromPath(t *testing.T) {
 		}
 	}
 }
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    string
+	}{
+		{"env shebang", "run", "#!/usr/bin/env python3\nprint(1)\n", "python"},
+		{"env options", "run", "#!/usr/bin/env -S NODE_ENV=prod node --flag\n", "javascript"},
+		{"direct shebang", "deploy", "#!/bin/sh\nset -e\n", "bash"},
+		{"versioned interpreter", "tool", "#!/usr/local/bin/python3.12\n", "python"},
+		{"unknown interpreter", "tool", "#!/usr/bin/frobnicate\n", ""},
+		{"shebang over extension", "tool.txt", "#!/bin/bash\n", "bash"},
+		{"file name over shebang", "Makefile", "#!/usr/bin/make -f\n", "makefile"},
+		{"bazel build file", "pkg/BUILD", "go_library(name = \"x\")\n", "starlark"},
+		{"script named build", "build", "#!/usr/bin/env bash\necho hi\n", "bash"},
+		{"script named workspace", "Workspace", "#!/bin/sh\n", "bash"},
+		{"vim modeline at end", "conf", "x\ny\n# vim: set ts=2 ft=sh:\n", "bash"},
+		{"vim modeline at start", "notes.txt", "// vi: filetype=cpp\n", "cpp"},
+		{"modeline over file name", "Makefile", "# vim: syntax=make\n", "makefile"},
+		{"emacs mode", "x.txt", "// -*- mode: c++; indent-tabs-mode: nil -*-\n", "cpp"},
+		{"emacs short form", "x", "#!/bin/sh\n# -*- python -*-\n", "python"},
+		{"emacs on third line ignored", "x.txt", "a\nb\n-*- python -*-\n", "text"},
+		{"extension fallback", "main.go", "package main\n", "go"},
+	}
+
+	for _, tt := range tests {
+		data := []byte(tt.content)
+		if got := detectLanguage(tt.path, data, data, nil); got != tt.want {
+			t.Errorf("%s: detectLanguage(%q) = %q, want %q", tt.name, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDetectLanguage_Custom(t *testing.T) {
+	custom := map[string]string{".tpl": "gotemplate", ".go": "golang", "jenkinsfile.ci": "groovy"}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"views/page.TPL", "gotemplate"},
+		{"main.go", "golang"},
+		{"ci/Jenkinsfile.ci", "groovy"},
+		{"app.py", "python"},
+	}
+
+	for _, tt := range tests {
+		if got := detectLanguage(tt.path, nil, nil, custom); got != tt.want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSplitLanguage(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "odd::name")
+	if err := os.WriteFile(existing, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		arg, path, lang string
+	}{
+		{"page.tpl::gotemplate", "page.tpl", "gotemplate"},
+		{"src/a.h::c++", "src/a.h", "c++"},
+		{"main.go", "main.go", ""},
+		{"a::b/c.go", "a::b/c.go", ""},
+		{existing, existing, ""},
+	}
+
+	for _, tt := range tests {
+		path, lang := splitLanguage(tt.arg)
+		if path != tt.path || lang != tt.lang {
+			t.Errorf("splitLanguage(%q) = %q, %q; want %q, %q", tt.arg, path, lang, tt.path, tt.lang)
+		}
+	}
+}
+
+func TestLanguageFromPath_Ecosystems(t *testing.T) {
+	tests := map[string]string{
+		"Main.kt":            "kotlin",
+		"App.swift":          "swift",
+		"Build.scala":        "scala",
+		"Program.cs":         "csharp",
+		"Lib.fs":             "fsharp",
+		"schema.sql":         "sql",
+		"schema.graphql":     "graphql",
+		"api.proto":          "protobuf",
+		"main.tf":            "terraform",
+		"job.hcl":            "hcl",
+		"init.lua":           "lua",
+		"analysis.R":         "r",
+		"solve.jl":           "julia",
+		"main.dart":          "dart",
+		"server.ex":          "elixir",
+		"gen_server.erl":     "erlang",
+		"Main.hs":            "haskell",
+		"parser.ml":          "ocaml",
+		"build.zig":          "zig",
+		"app.nim":            "nim",
+		"App.vue":            "vue",
+		"Button.svelte":      "svelte",
+		"pom.xml":            "xml",
+		"setup.cfg":          "ini",
+		".env":               "dotenv",
+		"deploy.ps1":         "powershell",
+		"build.bat":          "batch",
+		"flake.nix":          "nix",
+		"rules.mk":           "makefile",
+		"meson.build":        "meson",
+		"nginx.conf":         "nginx",
+		"defs.bzl":           "starlark",
+		"ci/BUILD.bazel":     "starlark",
+		"components/App.mjs": "javascript",
+	}
+
+	for path, want := range tests {
+		if got := languageFromPath(path); got != want {
+			t.Errorf("languageFromPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestParseLanguageTable(t *testing.T) {
+	exts, names, err := parseLanguageTable([]byte("# comment\n\ngo .go go.mod  # Go\nruby .RB Gemfile\nstarlark =BUILD\n"))
+	if err != nil {
+		t.Fatalf("parseLanguageTable error: %v", err)
+	}
+	if exts[".go"] != "go" || exts[".rb"] != "ruby" || names["go.mod"] != "go" || names["gemfile"] != "ruby" ||
+		names["BUILD"] != "starlark" || names["build"] != "" {
+		t.Errorf("parseLanguageTable = %v, %v", exts, names)
+	}
+
+	for _, bad := range []string{"go\n", "c .h\ncpp .h\n"} {
+		if _, _, err := parseLanguageTable([]byte(bad)); err == nil {
+			t.Errorf("parseLanguageTable(%q) expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package lx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Line number presets for Runner.LineNumberFormat. Any other value is a
+// template in which {line} is replaced by the right-aligned line number.
+const (
+	LineNumbersPlain  = "plain"  // "42: "
+	LineNumbersPadded = "padded" // " 42: "
+	LineNumbersGutter = "gutter" // " 42 | "
+	LineNumbersL      = "L"      // "L42: "
+	LineNumbersTab    = "tab"    // "42\t"
+)
+
+// lineNumberFormat describes the text written before each numbered line.
+type lineNumberFormat struct {
+	before, after string
+	pad           bool
+}
+
+func parseLineNumberFormat(spec string) (lineNumberFormat, error) {
+	switch spec {
+	case "", LineNumbersPlain:
+		return lineNumberFormat{after: ": "}, nil
+	case LineNumbersPadded:
+		return lineNumberFormat{after: ": ", pad: true}, nil
+	case LineNumbersGutter:
+		return lineNumberFormat{after: " | ", pad: true}, nil
+	case LineNumbersL:
+		return lineNumberFormat{before: "L", after: ": "}, nil
+	case LineNumbersTab:
+		return lineNumberFormat{after: "\t"}, nil
+	}
+
+	before, after, ok := strings.Cut(spec, "{line}")
+	if !ok {
+		return lineNumberFormat{}, fmt.Errorf("invalid --line-number-format %q: "+
+			"use plain, padded, gutter, L, tab or a template containing {line}", spec)
+	}
+	return lineNumberFormat{before: before, after: after, pad: true}, nil
+}
+
+func validateLineNumberFormat(spec string) error {
+	_, err := parseLineNumberFormat(spec)
+	return err
+}
+
+// appendNumber appends the prefix for line n, right-aligning the number to
+// width digits when the format is padded.
+func (f lineNumberFormat) appendNumber(buf []byte, n, width int) []byte {
+	buf = append(buf, f.before...)
+	if f.pad {
+		for i := len(strconv.Itoa(n)); i < width; i++ {
+			buf = append(buf, ' ')
+		}
+	}
+	buf = strconv.AppendInt(buf, int64(n), 10)
+	return append(buf, f.after...)
+}
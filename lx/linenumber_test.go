@@ -0,0 +1,65 @@
+package lx
+
+import "testing"
+
+func TestParseLineNumberFormat(t *testing.T) {
+	tests := []struct {
+		spec string
+		n    int
+		want string
+	}{
+		{"", 7, "7: "},
+		{LineNumbersPlain, 7, "7: "},
+		{LineNumbersPadded, 7, "  7: "},
+		{LineNumbersGutter, 42, " 42 | "},
+		{LineNumbersL, 7, "L7: "},
+		{LineNumbersTab, 7, "7\t"},
+		{"#{line} ", 7, "#  7 "},
+	}
+
+	for _, tt := range tests {
+		f, err := parseLineNumberFormat(tt.spec)
+		if err != nil {
+			t.Fatalf("parseLineNumberFormat(%q) error: %v", tt.spec, err)
+		}
+		if got := string(f.appendNumber(nil, tt.n, 3)); got != tt.want {
+			t.Errorf("format %q for %d = %q, want %q", tt.spec, tt.n, got, tt.want)
+		}
+	}
+
+	if _, err := parseLineNumberFormat("numbers"); err == nil {
+		t.Errorf("parseLineNumberFormat(%q) succeeded, want error", "numbers")
+	}
+}
+
+func TestAddLineNumbers_WidthFromHighestLine(t *testing.T) {
+	var data []byte
+	for range 12 {
+		data = append(data, "x\n"...)
+	}
+	gutter, _ := parseLineNumberFormat(LineNumbersGutter)
+
+	rows := sliceRows(numberRows(data, 1), 2, 2, ellipsis{})
+	body, _ := addLineNumbers(rows, gutter, 12)
+	got := string(body)
+	want := " 1 | x\n 2 | x\n... (8 rows skipped)\n11 | x\n12 | x\n"
+	if got != want {
+		t.Errorf("addLineNumbers = %q, want %q", got, want)
+	}
+}
+
+func TestAddLineNumbers_WidthFromFileLength(t *testing.T) {
+	var data []byte
+	for range 120 {
+		data = append(data, "x\n"...)
+	}
+	gutter, _ := parseLineNumberFormat(LineNumbersGutter)
+
+	rows := sliceRows(numberRows(data, 1), 2, 0, ellipsis{})
+	body, _ := addLineNumbers(rows, gutter, 120)
+	got := string(body)
+	want := "  1 | x\n  2 | x\n"
+	if got != want {
+		t.Errorf("addLineNumbers = %q, want %q", got, want)
+	}
+}
@@ -3,167 +3,183 @@ package lx
 import (
 	"bytes"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
-// countLines counts the number of newline-terminated rows.
-// Files without a trailing newline still count their last row.
+// countLines counts the number of logical rows. Rows end with "\n", "\r\n"
+// or a lone "\r"; files without a trailing line ending still count their last
+// row.
 func countLines(data []byte) int {
 	if len(data) == 0 {
 		return 0
 	}
 
-	// Count '\n' characters.
+	// Count line feeds plus carriage returns that aren't part of a CRLF.
 	n := bytes.Count(data, []byte("\n"))
-	// If the last byte is not '\n', there's one more logical line.
-	if data[len(data)-1] != '\n' {
+	n += bytes.Count(data, []byte("\r")) - bytes.Count(data, []byte("\r\n"))
+	// If the data doesn't end with a line ending, there's one more row.
+	if last := data[len(data)-1]; last != '\n' && last != '\r' {
 		n++
 	}
 	return n
 }
 
-// prepareView computes the sliced view of data based on head/tail and returns
-// both the view and the total number of logical rows in the original data.
-func prepareView(data []byte, head, tail int) ([]byte, int) {
-	if len(data) == 0 {
-		return data, 0
-	}
+// defaultEllipsis is the marker template used when none is configured.
+const defaultEllipsis = "... ({skipped} rows skipped)"
 
-	lines := splitLines(data)
-	total := len(lines)
+// ellipsis renders the marker printed in place of skipped rows from a
+// template with {skipped}, {from}, {to} and {n} placeholders.
+type ellipsis struct {
+	template string
+}
 
-	// No slicing: full file.
-	if head <= 0 && tail <= 0 {
-		return data, total
+// line renders the marker for skipped rows numbered from..to in the file,
+// terminated by a line ending.
+func (e ellipsis) line(from, to, skipped int) []byte {
+	tmpl := e.template
+	if tmpl == "" {
+		tmpl = defaultEllipsis
 	}
+	return []byte(strings.NewReplacer(
+		"{skipped}", strconv.Itoa(skipped),
+		"{from}", strconv.Itoa(from),
+		"{to}", strconv.Itoa(to),
+		"{n}", "\n",
+	).Replace(tmpl) + "\n")
+}
 
-	// If head or tail alone covers file, or together cover it, return full.
-	if head >= total || tail >= total || (head > 0 && tail > 0 && head+tail >= total) {
-		return data, total
+// splitLines splits data into logical lines, each keeping its line ending
+// ("\n", "\r\n" or a lone "\r"). No empty chunk is produced after a final
+// line ending.
+func splitLines(data []byte) [][]byte {
+	if len(data) == 0 {
+		return nil
 	}
 
-	var out [][]byte
+	var lines [][]byte
+	start := 0
+	for start < len(data) {
+		i := bytes.IndexAny(data[start:], "\r\n")
+		if i < 0 {
+			lines = append(lines, data[start:])
+			break
+		}
+		end := start + i + 1
+		if data[end-1] == '\r' && end < len(data) && data[end] == '\n' {
+			end++
+		}
+		lines = append(lines, data[start:end])
+		start = end
+	}
+	return lines
+}
 
-	switch {
-	case head > 0 && tail > 0:
-		// Both specified; include an explanatory ellipsis line.
-		skipped := total - head - tail
+// truncateLines shortens every line longer than maxLen characters, replacing
+// the rest with a marker such as "… (+48213 chars)". Line endings are kept,
+// so row counts and line numbers are unaffected.
+func truncateLines(data []byte, maxLen int) []byte {
+	if maxLen <= 0 || len(data) <= maxLen {
+		return data
+	}
+
+	out := make([]byte, 0, len(data))
+	for _, line := range splitLines(data) {
+		body := bytes.TrimRight(line, "\r\n")
+		ending := line[len(body):]
 
-		out = append(out, lines[:head]...)
-		out = append(out, []byte("... ("+strconv.Itoa(skipped)+" rows skipped)\n"))
-		out = append(out, lines[total-tail:]...)
+		if len(body) <= maxLen || utf8.RuneCount(body) <= maxLen {
+			out = append(out, line...)
+			continue
+		}
 
-	case head > 0:
-		out = lines[:head]
+		cut, kept := 0, 0
+		for kept < maxLen {
+			_, size := utf8.DecodeRune(body[cut:])
+			cut += size
+			kept++
+		}
+		rest := utf8.RuneCount(body[cut:])
 
-	case tail > 0:
-		out = lines[total-tail:]
+		out = append(out, body[:cut]...)
+		out = append(out, "… (+"+strconv.Itoa(rest)+" chars)"...)
+		out = append(out, ending...)
 	}
-
-	return bytes.Join(out, nil), total
+	return out
 }
 
-// splitLines splits data into logical lines, trimming the last empty chunk
-// when the file ends with a newline.
-func splitLines(data []byte) [][]byte {
-	if len(data) == 0 {
-		return nil
+// Thresholds used by looksMinified. Short files are never flagged on average
+// line length alone.
+const (
+	minifiedMinSize       = 1024
+	minifiedAvgLineLength = 250
+	minifiedMaxLineLength = 5000
+)
+
+// looksMinified reports whether content with the given size, row count and
+// longest row (in bytes) looks like minified or generated code.
+func looksMinified(size int64, rows, longest int) bool {
+	if rows == 0 {
+		return false
 	}
-	lines := bytes.SplitAfter(data, []byte("\n"))
-	if len(lines[len(lines)-1]) == 0 {
-		lines = lines[:len(lines)-1]
+	if longest > minifiedMaxLineLength {
+		return true
 	}
-	return lines
+	return size >= minifiedMinSize && size/int64(rows) > minifiedAvgLineLength
+}
+
+// longestRow returns the length in bytes of the longest row, excluding its
+// line ending.
+func longestRow(rows []row) int {
+	longest := 0
+	for _, r := range rows {
+		longest = max(longest, len(bytes.TrimRight(r.text, "\r\n")))
+	}
+	return longest
 }
 
 // sliceLines returns data restricted by head/tail settings.
 // Adds an explicit "... (N rows skipped)\n" line when both are used
 // and the slice omits middle rows.
 func sliceLines(data []byte, head, tail int) []byte {
-	view, _ := prepareView(data, head, tail)
-	return view
+	return joinRows(sliceRows(numberRows(data, 1), head, tail, ellipsis{}))
 }
 
-// addLineNumbers prefixes each logical line with "N: ", where N is the
-// original file line number. Ellipsis lines ("... (N rows skipped)") are left
-// unnumbered.
-func addLineNumbers(data []byte, totalRows, head, tail int) []byte {
-	if len(data) == 0 {
-		return data
-	}
-
-	lines := splitLines(data)
-	if len(lines) == 0 {
-		return data
+// addLineNumbers joins rows, prefixing each with its file line number
+// formatted by format and padded to the width of the highest line number in
+// the file, total, so that numbers line up across slices of it. Gap markers
+// are left unnumbered. It also returns the offsets at which numbered rows
+// start, see outputBlock.rowStarts.
+func addLineNumbers(rows []row, format lineNumberFormat, total int) ([]byte, []int) {
+	highest, size := max(total, 1), 0
+	for _, r := range rows {
+		highest = max(highest, r.num)
+		size += len(r.text)
 	}
-
-	// Helper to number a slice of lines starting at a given file line number.
-	numberLines := func(lines [][]byte, startLine int) []byte {
-		buf := make([]byte, 0, len(data)+len(lines)*8)
-		lineNum := startLine
-		for _, ln := range lines {
-			num := strconv.Itoa(lineNum)
-			buf = append(buf, []byte(num)...)
-			buf = append(buf, ':', ' ')
-			buf = append(buf, ln...)
-			lineNum++
+	width := len(strconv.Itoa(highest))
+
+	var starts []int
+	buf := make([]byte, 0, size+len(rows)*(width+len(format.before)+len(format.after)))
+	for _, r := range rows {
+		if r.num > 0 {
+			starts = append(starts, len(buf))
+			buf = format.appendNumber(buf, r.num, width)
 		}
-		return buf
+		buf = append(buf, r.text...)
 	}
+	return buf, starts
+}
 
-	// Decide if we effectively printed the full file (no slicing or slicing
-	// that covers all rows). In that case, number sequentially 1..totalRows.
-	fullFile := (head <= 0 && tail <= 0) ||
-		head >= totalRows ||
-		tail >= totalRows ||
-		(head > 0 && tail > 0 && head+tail >= totalRows)
-
-	if fullFile {
-		return numberLines(lines, 1)
-	}
-
-	// Now we know that slicing actually removed some rows.
-	switch {
-	case head > 0 && tail > 0 && head+tail < totalRows:
-		// Mixed head+tail with an ellipsis line in the middle.
-		// Layout of `lines` (from sliceLines):
-		//   lines[0:head]           => original 1..head
-		//   lines[head]             => ellipsis ("... (N rows skipped)")
-		//   lines[head+1:]          => original (totalRows-tail+1)..totalRows
-		buf := make([]byte, 0, len(data)+len(lines)*8)
-
-		// First head lines: line numbers 1..head.
-		if head > len(lines) {
-			head = len(lines)
-		}
-		if head > 0 {
-			buf = append(buf, numberLines(lines[:head], 1)...)
-		}
-
-		// Ellipsis line (if present) unnumbered.
-		if head < len(lines) {
-			buf = append(buf, lines[head]...)
-		}
-
-		// Tail lines: their original line numbers start at totalRows-tail+1.
-		if head+1 < len(lines) {
-			startOrig := totalRows - tail + 1
-			// lines[head+1:] corresponds exactly to tail lines.
-			bufTail := numberLines(lines[head+1:], startOrig)
-			buf = append(buf, bufTail...)
+// rowStarts returns the offsets at which content rows start in the rows
+// joined by joinRows.
+func rowStarts(rows []row) []int {
+	var starts []int
+	offset := 0
+	for _, r := range rows {
+		if r.num > 0 {
+			starts = append(starts, offset)
 		}
-
-		return buf
-
-	case head > 0 && (tail <= 0):
-		// Only head: first head lines are printed, numbered from 1.
-		return numberLines(lines, 1)
-
-	case tail > 0 && (head <= 0):
-		// Only tail: last tail lines are printed, numbered from totalRows-tail+1.
-		startOrig := totalRows - tail + 1
-		return numberLines(lines, startOrig)
+		offset += len(r.text)
 	}
-
-	// Fallback: sequential numbering (should not normally reach here).
-	return numberLines(lines, 1)
+	return starts
 }
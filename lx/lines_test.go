@@ -36,6 +36,21 @@ func TestCountLines(t *testing.T) {
 			input: "a\nb\nc",
 			want:  3,
 		},
+		{
+			name:  "crlf line endings",
+			input: "a\r\nb\r\n",
+			want:  2,
+		},
+		{
+			name:  "lone carriage returns",
+			input: "a\rb\rc",
+			want:  3,
+		},
+		{
+			name:  "mixed line endings",
+			input: "a\r\nb\nc\rd\r",
+			want:  4,
+		},
 	}
 
 	for _, tt := range tests {
@@ -82,6 +97,11 @@ func TestSplitLines(t *testing.T) {
 			input: "a\n\n",
 			want:  []string{"a\n", "\n"},
 		},
+		{
+			name:  "mixed line endings",
+			input: "a\r\nb\rc\n\r",
+			want:  []string{"a\r\n", "b\r", "c\n", "\r"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -171,3 +191,69 @@ func TestSliceLines_HeadTailCoverAll(t *testing.T) {
 		t.Errorf("sliceLines head+tail cover all changed data: got %q, want %q", got, input)
 	}
 }
+
+func TestTruncateLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		maxLen int
+		want   string
+	}{
+		{"no limit", "abcdef\n", 0, "abcdef\n"},
+		{"short lines", "abc\nde\n", 3, "abc\nde\n"},
+		{"long line", "abcdefgh\nxy\n", 3, "abc… (+5 chars)\nxy\n"},
+		{"crlf kept", "abcdefgh\r\n", 4, "abcd… (+4 chars)\r\n"},
+		{"no final newline", "abcdefgh", 2, "ab… (+6 chars)"},
+		{"counts characters", "ééééé\n", 2, "éé… (+3 chars)\n"},
+	}
+
+	for _, tt := range tests {
+		got := truncateLines([]byte(tt.input), tt.maxLen)
+		if string(got) != tt.want {
+			t.Errorf("%s: truncateLines(%q, %d) = %q, want %q", tt.name, tt.input, tt.maxLen, got, tt.want)
+		}
+	}
+}
+
+func TestLooksMinified(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int64
+		rows    int
+		longest int
+		want    bool
+	}{
+		{"empty", 0, 0, 0, false},
+		{"regular source", 40000, 1000, 120, false},
+		{"short one-liner", 300, 1, 300, false},
+		{"bundle", 200000, 3, 199000, true},
+		{"long average", 30000, 100, 900, true},
+		{"one huge line", 100000, 5000, 6000, true},
+	}
+
+	for _, tt := range tests {
+		if got := looksMinified(tt.size, tt.rows, tt.longest); got != tt.want {
+			t.Errorf("%s: looksMinified(%d, %d, %d) = %v, want %v",
+				tt.name, tt.size, tt.rows, tt.longest, got, tt.want)
+		}
+	}
+}
+
+func TestEllipsisLine(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"", "... (920 rows skipped)\n"},
+		{"// lines {from}-{to} omitted", "// lines 41-960 omitted\n"},
+		{"# {skipped} more", "# 920 more\n"},
+		{"/*{n} {from}-{to}{n}*/", "/*\n 41-960\n*/\n"},
+	}
+
+	for _, tt := range tests {
+		gap := ellipsis{template: tt.template}
+		if got := string(gap.line(41, 960, 920)); got != tt.want {
+			t.Errorf("ellipsis %q line = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
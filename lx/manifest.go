@@ -0,0 +1,320 @@
+package lx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A manifest lists the context to render, one entry per line:
+//
+//	# comments start with '#', at the start of a line or after whitespace
+//	lx/runner.go
+//	lx/**/*_test.go -h20          # globs, with per-entry options
+//	lx/lines.go:40-80 -l          # row ranges (also :40 and :40-)
+//	lx/config.go --symbol Effective
+//	templates/*.tpl::gotemplate   # fence language override
+//	"docs/with space.md"
+//
+// Relative paths and globs are resolved against the manifest's own
+// directory, so that a checked-in manifest works from anywhere. Per-entry
+// options override the options given on the command line for that entry
+// only.
+
+var rangeSuffix = regexp.MustCompile(`^(.+):(\d+)(-(\d*))?$`)
+
+// LoadManifest reads the manifest at path and expands it into entries.
+func LoadManifest(path string, base Options) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open manifest: %w", err)
+	}
+	defer f.Close()
+
+	entries, err := ParseManifest(f, filepath.Dir(path), base)
+	if err != nil {
+		return nil, fmt.Errorf("manifest %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// ParseManifest parses manifest lines from rd, resolving relative paths
+// against dir. Every entry gets its own Runner derived from base plus the
+// entry's options.
+func ParseManifest(rd io.Reader, dir string, base Options) ([]Entry, error) {
+	var entries []Entry
+
+	sc := bufio.NewScanner(rd)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+
+		fields, err := splitManifestLine(sc.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		spec, lang := splitLanguage(fields[0])
+		opts := base
+
+		if m := rangeSuffix.FindStringSubmatch(spec); m != nil {
+			spec = m[1]
+			opts.From, _ = strconv.Atoi(m[2])
+			switch {
+			case m[3] == "":
+				opts.To = opts.From
+			case m[4] == "":
+				opts.To = 0
+			default:
+				opts.To, _ = strconv.Atoi(m[4])
+			}
+		}
+
+		if err := applyEntryOptions(&opts, NormalizeArgs(fields[1:])); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		if !filepath.IsAbs(spec) {
+			spec = filepath.Join(dir, spec)
+		}
+		paths, err := expandGlob(spec)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("line %d: pattern %q matched no files", lineNo, spec)
+		}
+
+		r := opts.Effective()
+		for _, p := range paths {
+			entries = append(entries, Entry{Path: p, Runner: &r, Language: lang})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// splitManifestLine splits a manifest line into whitespace-separated fields,
+// honouring single and double quotes and dropping comments.
+func splitManifestLine(line string) ([]string, error) {
+	var (
+		fields  []string
+		cur     strings.Builder
+		inField bool
+		quote   rune
+	)
+
+	for _, ch := range line {
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(ch)
+			}
+
+		case ch == '\'' || ch == '"':
+			quote = ch
+			inField = true
+
+		case ch == ' ' || ch == '\t':
+			if inField {
+				fields = append(fields, cur.String())
+				cur.Reset()
+				inField = false
+			}
+
+		case ch == '#' && !inField:
+			return fields, nil
+
+		default:
+			cur.WriteRune(ch)
+			inField = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inField {
+		fields = append(fields, cur.String())
+	}
+	return fields, nil
+}
+
+// applyEntryOptions applies per-entry manifest options to opts.
+func applyEntryOptions(opts *Options, args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("unexpected argument %q", arg)
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+
+		// takeValue consumes the option's value, inline or from the next field.
+		takeValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("option %s requires a value", arg)
+			}
+			i++
+			return args[i], nil
+		}
+		takeInt := func() (int, error) {
+			v, err := takeValue()
+			if err != nil {
+				return 0, err
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return 0, fmt.Errorf("option %s: invalid number %q", arg, v)
+			}
+			return n, nil
+		}
+		takePattern := func() (string, error) {
+			v, err := takeValue()
+			if err != nil {
+				return "", err
+			}
+			if err := validatePattern(v); err != nil {
+				return "", fmt.Errorf("option %s: %w", arg, err)
+			}
+			return v, nil
+		}
+
+		var err error
+		switch name {
+		case "h", "head":
+			opts.Head, err = takeInt()
+			opts.HeadSet = true
+		case "t", "tail":
+			opts.Tail, err = takeInt()
+			opts.TailSet = true
+		case "n":
+			opts.NBoth, err = takeInt()
+			opts.NSet = true
+		case "l", "line-numbers":
+			opts.LineNumbers = true
+		case "symbol":
+			opts.Symbol, err = takeValue()
+		case "from-regex":
+			opts.FromRegex, err = takePattern()
+		case "to-regex":
+			opts.ToRegex, err = takePattern()
+		default:
+			return fmt.Errorf("unknown option %q", arg)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandGlob expands pattern into the regular files it matches. Besides the
+// filepath.Match syntax, a "**" path segment matches any number of
+// directories. Patterns without meta characters are returned unchanged so
+// that missing files are reported when they are read.
+func expandGlob(pattern string) ([]string, error) {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return []string{pattern}, nil
+	}
+
+	slashed := filepath.ToSlash(pattern)
+	var matches []string
+
+	if !strings.Contains(slashed, "**") {
+		found, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+		for _, p := range found {
+			if info, err := os.Stat(p); err == nil && info.Mode().IsRegular() {
+				matches = append(matches, p)
+			}
+		}
+		return matches, nil
+	}
+
+	// Walk from the longest prefix without meta characters.
+	segs := strings.Split(slashed, "/")
+	rootLen := 0
+	for rootLen < len(segs) && !strings.ContainsAny(segs[rootLen], "*?[") {
+		rootLen++
+	}
+	root := strings.Join(segs[:rootLen], "/")
+	if root == "" {
+		if strings.HasPrefix(slashed, "/") {
+			root = "/"
+		} else {
+			root = "."
+		}
+	}
+	rest := segs[rootLen:]
+
+	err := filepath.WalkDir(filepath.FromSlash(root), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(root), p)
+		if err != nil {
+			return err
+		}
+		ok, err := matchSegments(rest, strings.Split(filepath.ToSlash(rel), "/"))
+		if err != nil {
+			return fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+		if ok {
+			matches = append(matches, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// matchSegments reports whether the path segments match the pattern
+// segments, where a "**" pattern segment matches zero or more path segments.
+func matchSegments(pattern, segs []string) (bool, error) {
+	if len(pattern) == 0 {
+		return len(segs) == 0, nil
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segs); i++ {
+			ok, err := matchSegments(pattern[1:], segs[i:])
+			if ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+	if len(segs) == 0 {
+		return false, nil
+	}
+	ok, err := path.Match(pattern[0], segs[0])
+	if !ok || err != nil {
+		return false, err
+	}
+	return matchSegments(pattern[1:], segs[1:])
+}
@@ -0,0 +1,191 @@
+package lx
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitManifestLine(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{name: "empty", in: "", want: nil},
+		{name: "comment only", in: "  # just a comment", want: nil},
+		{name: "path", in: "lx/runner.go", want: []string{"lx/runner.go"}},
+		{name: "options", in: "a.go -h5\t-l", want: []string{"a.go", "-h5", "-l"}},
+		{name: "trailing comment", in: "a.go -l # why", want: []string{"a.go", "-l"}},
+		{name: "hash inside field", in: "a#b.go", want: []string{"a#b.go"}},
+		{name: "quoted", in: `"my file.go" '--symbol' x`, want: []string{"my file.go", "--symbol", "x"}},
+	}
+
+	for _, tt := range tests {
+		got, err := splitManifestLine(tt.in)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: splitManifestLine(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := splitManifestLine(`"unterminated`); err == nil {
+		t.Errorf("expected error for unterminated quote")
+	}
+}
+
+func TestExpandGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, p := range []string{"a.go", "b.txt", "sub/c.go", "sub/deep/d.go"} {
+		full := filepath.Join(dir, p)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.go", []string{"a.go"}},
+		{"**/*.go", []string{"a.go", "sub/c.go", "sub/deep/d.go"}},
+		{"sub/**/*.go", []string{"sub/c.go", "sub/deep/d.go"}},
+		{"*", []string{"a.go", "b.txt"}},
+		{"missing.go", []string{"missing.go"}},
+	}
+
+	for _, tt := range tests {
+		got, err := expandGlob(filepath.Join(dir, tt.pattern))
+		if err != nil {
+			t.Fatalf("expandGlob(%q) error: %v", tt.pattern, err)
+		}
+		var rel []string
+		for _, p := range got {
+			r, err := filepath.Rel(dir, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rel = append(rel, filepath.ToSlash(r))
+		}
+		if !reflect.DeepEqual(rel, tt.want) {
+			t.Errorf("expandGlob(%q) = %q, want %q", tt.pattern, rel, tt.want)
+		}
+	}
+}
+
+func TestParseManifest_EntriesAndOptions(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	for _, p := range []string{a, b} {
+		if err := os.WriteFile(p, []byte("1\n2\n3\n4\n5\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manifest := "# context for the bug\n" +
+		a + " -h2\n" +
+		"\n" +
+		b + ":2-4 -l  # middle only\n"
+
+	base := Options{
+		Tail:             1,
+		TailSet:          true,
+		PrefixDelimiter:  "{filename}{n}",
+		PostfixDelimiter: "--{n}",
+	}
+	entries, err := ParseManifest(strings.NewReader(manifest), ".", base)
+	if err != nil {
+		t.Fatalf("ParseManifest error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+
+	ra := entries[0].Runner
+	if entries[0].Path != a || ra.Head != 2 || ra.Tail != 1 || ra.LineNumbers {
+		t.Errorf("entry a = %q %+v, want head 2, inherited tail 1, no line numbers", entries[0].Path, *ra)
+	}
+	rb := entries[1].Runner
+	if entries[1].Path != b || rb.From != 2 || rb.To != 4 || !rb.LineNumbers {
+		t.Errorf("entry b = %q %+v, want range 2-4 with line numbers", entries[1].Path, *rb)
+	}
+
+	var buf bytes.Buffer
+	if err := base.Effective().RunEntries(entries, &buf); err != nil {
+		t.Fatalf("RunEntries error: %v", err)
+	}
+	want := a + "\n1\n2\n... (2 rows skipped)\n5\n--\n" + b + "\n4: 4\n--\n"
+	if buf.String() != want {
+		t.Errorf("RunEntries output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestParseManifest_Errors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  string
+	}{
+		{"unknown option", "a.txt --bogus\n", `line 1: unknown option "--bogus"`},
+		{"missing value", "a.txt\nb.txt -h\n", "line 2: option -h requires a value"},
+		{"bad number", "a.txt --tail=x\n", `invalid number "x"`},
+		{"bad pattern", "a.txt --from-regex '('\n", "option --from-regex: error parsing regexp"},
+		{"no glob match", filepath.Join(dir, "*.none") + "\n", "matched no files"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseManifest(strings.NewReader(tt.manifest), ".", Options{})
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseManifest_LanguageOverride(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "page.tpl")
+	if err := os.WriteFile(path, []byte("{{ .Title }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := ParseManifest(strings.NewReader(path+":1::gotemplate -l\n"), ".", Options{})
+	if err != nil {
+		t.Fatalf("ParseManifest error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Path != path || entries[0].Language != "gotemplate" || entries[0].Runner.From != 1 {
+		t.Errorf("entries = %+v, want %q from row 1 as gotemplate", entries, path)
+	}
+}
+
+func TestLoadManifest_RelativeToManifest(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "context"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	manifest := filepath.Join(dir, "context", "a.lx")
+	if err := os.WriteFile(manifest, []byte("../a.go\n../*.go\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := LoadManifest(manifest, Options{})
+	if err != nil {
+		t.Fatalf("LoadManifest error: %v", err)
+	}
+	want := filepath.Join(dir, "a.go")
+	if len(entries) != 2 || entries[0].Path != want || entries[1].Path != want {
+		t.Errorf("entries = %+v, want %q twice", entries, want)
+	}
+}
@@ -0,0 +1,195 @@
+package lx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// notebookOutputLines caps each cell output rendered by --notebook-outputs.
+const notebookOutputLines = 20
+
+// notebook is the subset of the Jupyter notebook format (nbformat 4) that
+// is rendered.
+type notebook struct {
+	Metadata struct {
+		KernelSpec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+	Cells []notebookCell `json:"cells"`
+}
+
+type notebookCell struct {
+	CellType       string           `json:"cell_type"`
+	Source         notebookText     `json:"source"`
+	ExecutionCount *int             `json:"execution_count"`
+	Outputs        []notebookOutput `json:"outputs"`
+}
+
+type notebookOutput struct {
+	OutputType string                  `json:"output_type"`
+	Text       notebookText            `json:"text"`
+	Data       map[string]notebookText `json:"data"`
+	EName      string                  `json:"ename"`
+	EValue     string                  `json:"evalue"`
+}
+
+// notebookText is multiline text, stored either as a string or as a list of
+// lines.
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*t = notebookText(strings.Join(lines, ""))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		// Output data that isn't text, such as application/json, is left
+		// empty.
+		*t = ""
+		return nil
+	}
+	*t = notebookText(s)
+	return nil
+}
+
+// isNotebook reports whether path is a Jupyter notebook.
+func isNotebook(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".ipynb")
+}
+
+// renderNotebook renders a notebook in the jupytext "percent" style: each
+// cell starts with a "# %%" line giving its number, and markdown cells are
+// commented out in the kernel language. With outputs, text outputs follow
+// their cell, truncated, and other outputs such as images are replaced by
+// placeholders. It also returns the kernel language.
+func renderNotebook(data []byte, outputs bool) ([]byte, string, error) {
+	var nb notebook
+	if err := json.Unmarshal(data, &nb); err != nil {
+		return nil, "", fmt.Errorf("parse notebook: %w", err)
+	}
+
+	lang := strings.ToLower(nb.Metadata.KernelSpec.Language)
+	if lang == "" {
+		lang = strings.ToLower(nb.Metadata.LanguageInfo.Name)
+	}
+	if lang == "" {
+		lang = "python"
+	}
+	comment := lineComment(lang)
+
+	var buf bytes.Buffer
+	for i, cell := range nb.Cells {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "%s %%%% cell %d", comment, i+1)
+		if cell.CellType != "code" {
+			fmt.Fprintf(&buf, " [%s]", cell.CellType)
+		}
+		buf.WriteString("\n")
+
+		source := strings.TrimSuffix(string(cell.Source), "\n")
+		if cell.CellType != "code" {
+			writeCommented(&buf, comment, source)
+			continue
+		}
+		if source != "" {
+			buf.WriteString(source + "\n")
+		}
+
+		if !outputs || len(cell.Outputs) == 0 {
+			continue
+		}
+		count := ""
+		if cell.ExecutionCount != nil {
+			count = fmt.Sprint(*cell.ExecutionCount)
+		}
+		fmt.Fprintf(&buf, "%s Out[%s]:\n", comment, count)
+		for _, out := range cell.Outputs {
+			writeCommented(&buf, comment, truncateOutput(notebookOutputText(out)))
+		}
+	}
+	return buf.Bytes(), lang, nil
+}
+
+// notebookOutputText returns the text shown for a cell output.
+func notebookOutputText(out notebookOutput) string {
+	switch out.OutputType {
+	case "stream":
+		return string(out.Text)
+	case "error":
+		return out.EName + ": " + out.EValue
+	}
+
+	// execute_result and display_data carry one representation per MIME
+	// type. Anything richer than plain text is summarized, since the plain
+	// text of a figure is just its repr.
+	mimes := make([]string, 0, len(out.Data))
+	for mime := range out.Data {
+		if mime != "text/plain" {
+			mimes = append(mimes, mime)
+		}
+	}
+	slices.Sort(mimes)
+	for _, mime := range mimes {
+		if strings.HasPrefix(mime, "image/") {
+			return "[" + mime + " output omitted]"
+		}
+	}
+	if text, ok := out.Data["text/plain"]; ok {
+		return string(text)
+	}
+	if len(mimes) > 0 {
+		return "[" + mimes[0] + " output omitted]"
+	}
+	return ""
+}
+
+// truncateOutput keeps the first notebookOutputLines lines of text.
+func truncateOutput(text string) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if len(lines) <= notebookOutputLines {
+		return strings.Join(lines, "\n")
+	}
+	rest := len(lines) - notebookOutputLines
+	return strings.Join(lines[:notebookOutputLines], "\n") + fmt.Sprintf("\n... (%d more lines)", rest)
+}
+
+// writeCommented writes each line of text prefixed by comment.
+func writeCommented(buf *bytes.Buffer, comment, text string) {
+	if text == "" {
+		return
+	}
+	for line := range strings.SplitSeq(text, "\n") {
+		if line == "" {
+			buf.WriteString(comment + "\n")
+			continue
+		}
+		buf.WriteString(comment + " " + line + "\n")
+	}
+}
+
+// lineComment returns the line comment marker for a notebook kernel
+// language.
+func lineComment(lang string) string {
+	switch lang {
+	case "c", "c++", "cpp", "csharp", "c#", "go", "java", "javascript", "typescript",
+		"kotlin", "rust", "scala", "swift":
+		return "//"
+	case "sql", "haskell", "lua":
+		return "--"
+	case "matlab", "octave":
+		return "%"
+	}
+	return "#"
+}
@@ -0,0 +1,91 @@
+package lx
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testNotebook = `{
+ "metadata": {"kernelspec": {"language": "python", "name": "python3"}},
+ "nbformat": 4,
+ "cells": [
+  {"cell_type": "markdown", "source": ["# Load\n", "\n", "Read the data."]},
+  {"cell_type": "code", "execution_count": 3, "source": "df = load()\ndf.plot()",
+   "outputs": [
+    {"output_type": "stream", "name": "stdout", "text": ["loaded 10 rows\n"]},
+    {"output_type": "display_data", "data": {
+      "image/png": "iVBORw0KGgoAAAANSUhEUg==",
+      "text/plain": ["<Figure size 640x480>"]}},
+    {"output_type": "execute_result", "data": {"application/json": {"a": 1}}}
+   ]},
+  {"cell_type": "code", "execution_count": 4, "source": ["1/0"],
+   "outputs": [{"output_type": "error", "ename": "ZeroDivisionError", "evalue": "division by zero",
+     "traceback": ["\u001b[0;31m..."]}]}
+ ]
+}`
+
+func TestRenderNotebook(t *testing.T) {
+	got, lang, err := renderNotebook([]byte(testNotebook), false)
+	if err != nil {
+		t.Fatalf("renderNotebook error: %v", err)
+	}
+	want := "# %% cell 1 [markdown]\n# # Load\n#\n# Read the data.\n" +
+		"\n# %% cell 2\ndf = load()\ndf.plot()\n" +
+		"\n# %% cell 3\n1/0\n"
+	if string(got) != want || lang != "python" {
+		t.Errorf("renderNotebook = %q (%s), want %q (python)", got, lang, want)
+	}
+}
+
+func TestRenderNotebook_Outputs(t *testing.T) {
+	got, _, err := renderNotebook([]byte(testNotebook), true)
+	if err != nil {
+		t.Fatalf("renderNotebook error: %v", err)
+	}
+	for _, want := range []string{
+		"df.plot()\n# Out[3]:\n# loaded 10 rows\n# [image/png output omitted]\n# [application/json output omitted]\n",
+		"1/0\n# Out[4]:\n# ZeroDivisionError: division by zero\n",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("renderNotebook output = %q, want it to contain %q", got, want)
+		}
+	}
+	if strings.Contains(string(got), "iVBOR") {
+		t.Errorf("renderNotebook output contains image data: %q", got)
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	text := strings.Repeat("row\n", notebookOutputLines+5)
+	got := truncateOutput(text)
+	if n := strings.Count(got, "row"); n != notebookOutputLines {
+		t.Errorf("truncateOutput kept %d lines, want %d", n, notebookOutputLines)
+	}
+	if !strings.HasSuffix(got, "\n... (5 more lines)") {
+		t.Errorf("truncateOutput = %q, want a note about 5 more lines", got)
+	}
+}
+
+func TestRunner_NotebookUsesKernelLanguage(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "analysis.ipynb")
+	nb := `{"metadata": {"kernelspec": {"language": "R"}}, "cells": [` +
+		`{"cell_type": "code", "source": ["x <- 1\n", "x"], "outputs": []}]}`
+	if err := os.WriteFile(path, []byte(nb), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	r := NewRunner(0, 0, "{row_count} ```{language}{n}", "```{n}", false)
+	r.EOL = EOLLF
+	if err := r.Run([]string{path}, &buf); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	want := "3 ```r\n# %% cell 1\nx <- 1\nx\n```\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,157 @@
+package lx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// outputTarget routes rendered blocks to a file and/or the clipboard, or to
+// stdout when neither is set, optionally split into message-sized parts.
+type outputTarget struct {
+	Path       string
+	Clipboard  []string
+	SplitBytes int
+	Newline    string // ends the "Part i/n" headers of split output
+}
+
+// emit writes blocks to the target. Parts copied to the clipboard, or printed
+// to a terminal, are handed out one at a time, waiting for Enter in between.
+func (t outputTarget) emit(blocks []outputBlock, stdout io.Writer) error {
+	var parts []outputPart
+	if t.SplitBytes > 0 {
+		parts = splitParts(blocks, t.SplitBytes, t.Newline)
+	} else {
+		parts = []outputPart{wholePart(blocks)}
+	}
+
+	if t.Path != "" {
+		if err := writeParts(t.Path, parts); err != nil {
+			return err
+		}
+	}
+
+	if t.Clipboard != nil {
+		for i, p := range parts {
+			if i > 0 {
+				if err := waitForEnter(fmt.Sprintf("lx: press Enter to copy part %d/%d", i+1, len(parts))); err != nil {
+					return err
+				}
+			}
+			if err := copyToClipboard(t.Clipboard, p.data); err != nil {
+				return err
+			}
+			summary := copySummary(p.files, p.rows, p.data)
+			if len(parts) > 1 {
+				summary = fmt.Sprintf("part %d/%d: %s", i+1, len(parts), summary)
+			}
+			fmt.Fprintf(os.Stderr, "lx: %s\n", summary)
+		}
+	}
+
+	if t.Path == "" && t.Clipboard == nil {
+		pace := len(parts) > 1 && isTerminal(os.Stdout)
+		for i, p := range parts {
+			if i > 0 && pace {
+				if err := waitForEnter(fmt.Sprintf("lx: press Enter for part %d/%d", i+1, len(parts))); err != nil {
+					return err
+				}
+			}
+			if _, err := stdout.Write(p.data); err != nil {
+				return fmt.Errorf("write output: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeParts writes a single part to path, or several parts to numbered
+// files next to it (out.md -> out.part1.md, out.part2.md, ...). Output left
+// over from an earlier run that no longer applies is removed: part files
+// past the last part, or path itself when writing parts.
+func writeParts(path string, parts []outputPart) error {
+	written := 0
+	if len(parts) == 1 {
+		if err := writeFileAtomic(path, parts[0].data); err != nil {
+			return err
+		}
+	} else {
+		for i, p := range parts {
+			if err := writeFileAtomic(partPath(path, i+1), p.data); err != nil {
+				return err
+			}
+		}
+		written = len(parts)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove stale output: %w", err)
+		}
+	}
+
+	for i := written + 1; ; i++ {
+		if err := os.Remove(partPath(path, i)); err != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func partPath(path string, i int) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".part" + strconv.Itoa(i) + ext
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// waitForEnter prints prompt to stderr and waits for a line on the terminal.
+// The terminal is opened directly because stdin may carry filenames.
+func waitForEnter(prompt string) error {
+	name := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		name = "CONIN$"
+	}
+	tty, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("wait for next part: %w", err)
+	}
+	defer tty.Close()
+
+	fmt.Fprint(os.Stderr, prompt)
+	if _, err := bufio.NewReader(tty).ReadString('\n'); err != nil {
+		return fmt.Errorf("wait for next part: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces path with data via a temporary file in the same
+// directory, so readers never observe a partially written output.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("create output: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write output: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package lx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic_ReplacesContent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.md")
+	if err := os.WriteFile(path, []byte("old content\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte("new\n")); err != nil {
+		t.Fatalf("writeFileAtomic error: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "new\n" {
+		t.Errorf("content = %q, want %q", got, "new\n")
+	}
+
+	left, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 1 {
+		t.Errorf("temporary files left behind: %v", left)
+	}
+}
+
+func TestPartPath(t *testing.T) {
+	tests := map[string]string{
+		"out.md":         "out.part2.md",
+		"dir/ctx":        "dir/ctx.part2",
+		"a.b/context.md": "a.b/context.part2.md",
+	}
+	for in, want := range tests {
+		if got := partPath(in, 2); got != want {
+			t.Errorf("partPath(%q, 2) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWriteParts_RemovesStaleParts(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.md")
+
+	three := []outputPart{{data: []byte("1")}, {data: []byte("2")}, {data: []byte("3")}}
+	if err := writeParts(path, three); err != nil {
+		t.Fatal(err)
+	}
+	two := []outputPart{{data: []byte("one")}, {data: []byte("two")}}
+	if err := writeParts(path, two); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(partPath(path, 2))
+	if err != nil || string(got) != "two" {
+		t.Errorf("part 2 = %q, %v; want %q", got, err, "two")
+	}
+	if _, err := os.Stat(partPath(path, 3)); !os.IsNotExist(err) {
+		t.Errorf("stale part 3 still exists (err = %v)", err)
+	}
+}
+
+func TestWriteParts_SwitchingBetweenSingleAndSplit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.md")
+
+	if err := writeParts(path, []outputPart{{data: []byte("whole")}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeParts(path, []outputPart{{data: []byte("1")}, {data: []byte("2")}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("stale single-part output still exists (err = %v)", err)
+	}
+
+	if err := writeParts(path, []outputPart{{data: []byte("whole again")}}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 2; i++ {
+		if _, err := os.Stat(partPath(path, i)); !os.IsNotExist(err) {
+			t.Errorf("stale part %d still exists (err = %v)", i, err)
+		}
+	}
+	if got, err := os.ReadFile(path); err != nil || string(got) != "whole again" {
+		t.Errorf("output = %q, %v; want %q", got, err, "whole again")
+	}
+}
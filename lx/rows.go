@@ -0,0 +1,322 @@
+package lx
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// row is one line of a file view together with its 1-based line number in
+// the file. Gap markers standing in for skipped rows have number 0 and may
+// span several lines.
+type row struct {
+	text []byte
+	num  int
+	// repeats is the number of consecutive identical rows this row stands
+	// for after deduplication; 0 or 1 means just itself.
+	repeats int
+}
+
+// numberRows splits data into rows numbered from first.
+func numberRows(data []byte, first int) []row {
+	lines := splitLines(data)
+	rows := make([]row, len(lines))
+	for i, ln := range lines {
+		rows[i] = row{text: ln, num: first + i}
+	}
+	return rows
+}
+
+// joinRows concatenates the text of rows.
+func joinRows(rows []row) []byte {
+	n := 0
+	for _, r := range rows {
+		n += len(r.text)
+	}
+	out := make([]byte, 0, n)
+	for _, r := range rows {
+		out = append(out, r.text...)
+	}
+	return out
+}
+
+// contentRows counts the rows that aren't gap markers.
+func contentRows(rows []row) int {
+	n := 0
+	for _, r := range rows {
+		if r.num > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// appendGap appends a marker for the skipped rows to out, unless they
+// contain no file content.
+func appendGap(out, skipped []row, gap ellipsis) []row {
+	from, to, n := 0, 0, 0
+	for _, r := range skipped {
+		if r.num == 0 {
+			continue
+		}
+		if n == 0 {
+			from = r.num
+		}
+		to = r.num
+		n++
+	}
+	if n == 0 {
+		return out
+	}
+	return append(out, row{text: gap.line(from, to, n)})
+}
+
+// restrictRows returns the rows numbered from..to (inclusive). A bound of 0
+// leaves that side open; a range past the end is empty.
+func restrictRows(rows []row, from, to int) []row {
+	var out []row
+	for _, r := range rows {
+		if r.num > 0 && (r.num < from || to > 0 && r.num > to) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// sliceRows keeps the first head and last tail content rows, replacing the
+// rows in between with a gap marker. Zero leaves that side unlimited.
+func sliceRows(rows []row, head, tail int, gap ellipsis) []row {
+	total := contentRows(rows)
+
+	// No slicing, or head/tail alone or together cover everything.
+	if (head <= 0 && tail <= 0) || head >= total || tail >= total ||
+		(head > 0 && tail > 0 && head+tail >= total) {
+		return rows
+	}
+
+	// headEnd is the index just past the head-th content row and tailStart
+	// the index of the tail-th content row from the end.
+	headEnd, tailStart := 0, len(rows)
+	for i, n := 0, 0; head > 0 && n < head; i++ {
+		if rows[i].num > 0 {
+			n++
+		}
+		headEnd = i + 1
+	}
+	for i, n := len(rows)-1, 0; tail > 0 && n < tail; i-- {
+		if rows[i].num > 0 {
+			n++
+		}
+		tailStart = i
+	}
+
+	switch {
+	case head > 0 && tail > 0:
+		out := append([]row(nil), rows[:headEnd]...)
+		out = appendGap(out, rows[headEnd:tailStart], gap)
+		return append(out, rows[tailStart:]...)
+	case head > 0:
+		return rows[:headEnd]
+	default:
+		return rows[tailStart:]
+	}
+}
+
+// filterRows removes the content rows that don't match keep or that match
+// drop, returning the remaining rows and the number removed. Either pattern
+// may be nil.
+func filterRows(rows []row, keep, drop *regexp.Regexp) ([]row, int) {
+	out := rows[:0:0]
+	dropped := 0
+	for _, r := range rows {
+		if r.num > 0 {
+			text := bytes.TrimRight(r.text, "\r\n")
+			if keep != nil && !keep.Match(text) || drop != nil && drop.Match(text) {
+				dropped++
+				continue
+			}
+		}
+		out = append(out, r)
+	}
+	return out, dropped
+}
+
+// defaultDedupeNormalize matches the parts of a line ignored when comparing
+// lines for --dedupe-lines, unless other patterns are given: timestamps,
+// times of day, UUIDs and hexadecimal IDs.
+var defaultDedupeNormalize = []string{
+	`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`,
+	`\b\d{2}:\d{2}:\d{2}(\.\d+)?\b`,
+	`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`,
+	`(?i)\b0x[0-9a-f]+\b`,
+	`(?i)\b[0-9a-f]{12,}\b`,
+}
+
+// dedupeRows collapses runs of consecutive content rows that are identical
+// once the normalize patterns are removed into the first row of the run,
+// recording the run length in repeats.
+func dedupeRows(rows []row, normalize []*regexp.Regexp) []row {
+	key := func(r row) string {
+		text := bytes.TrimRight(r.text, "\r\n")
+		for _, re := range normalize {
+			text = re.ReplaceAll(text, nil)
+		}
+		return string(text)
+	}
+
+	var (
+		out     []row
+		lastKey string
+	)
+	for _, r := range rows {
+		if r.num == 0 {
+			out = append(out, r)
+			continue
+		}
+		k := key(r)
+		if n := len(out); n > 0 && out[n-1].num > 0 && k == lastKey {
+			out[n-1].repeats = max(out[n-1].repeats, 1) + 1
+			continue
+		}
+		out = append(out, r)
+		lastKey = k
+	}
+	return out
+}
+
+// annotateRepeats appends "(repeated N times)" to the text of a row that
+// stands for n > 1 rows, keeping its line ending last.
+func annotateRepeats(text []byte, n int) []byte {
+	if n <= 1 {
+		return text
+	}
+	body := bytes.TrimRight(text, "\r\n")
+	out := make([]byte, 0, len(text)+24)
+	out = append(out, body...)
+	out = fmt.Appendf(out, " (repeated %d times)", n)
+	return append(out, text[len(body):]...)
+}
+
+// selectSections keeps the sections of rows that start at a row matching
+// start and run through the next row matching end, joined by gap markers.
+// A nil start selects a single section from the first row; a nil end runs
+// each section to the last row.
+func selectSections(rows []row, start, end *regexp.Regexp, gap ellipsis) []row {
+	var (
+		out     []row
+		in      bool
+		started bool
+		last    = -1 // index of the last selected row
+	)
+
+	for i, r := range rows {
+		text := bytes.TrimRight(r.text, "\r\n")
+		switch {
+		case in:
+			// The end pattern is only looked for after the start row, so both
+			// may be the same pattern.
+			if r.num > 0 && end != nil && end.Match(text) {
+				in = false
+			}
+		case r.num > 0 && (start == nil && !started || start != nil && start.Match(text)):
+			in, started = true, true
+			if last >= 0 {
+				out = appendGap(out, rows[last+1:i], gap)
+			}
+		default:
+			continue
+		}
+		out = append(out, r)
+		last = i
+	}
+	return out
+}
+
+// matchContext keeps the rows matching re plus context content rows on
+// either side. Overlapping windows are merged and separate ones are joined
+// by gap markers. A negative context counts as 0.
+func matchContext(rows []row, re *regexp.Regexp, context int, gap ellipsis) []row {
+	context = max(context, 0)
+	var content []int // indexes of the content rows
+	for i, r := range rows {
+		if r.num > 0 {
+			content = append(content, i)
+		}
+	}
+
+	keep := make([]bool, len(rows))
+	for j, i := range content {
+		if !re.Match(bytes.TrimRight(rows[i].text, "\r\n")) {
+			continue
+		}
+		lo, hi := max(j-context, 0), min(j+context, len(content)-1)
+		// Markers inside the window are kept along with the content.
+		for k := content[lo]; k <= content[hi]; k++ {
+			keep[k] = true
+		}
+	}
+
+	var out []row
+	last := -1 // index of the last kept row
+	for i, r := range rows {
+		if !keep[i] {
+			continue
+		}
+		if last >= 0 {
+			out = appendGap(out, rows[last+1:i], gap)
+		}
+		out = append(out, r)
+		last = i
+	}
+	return out
+}
+
+// compilePatterns compiles the regular expressions given to flag.
+func compilePatterns(flag string, exprs []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		re, err := compilePattern(flag, expr)
+		if err != nil {
+			return nil, err
+		}
+		if re != nil {
+			res = append(res, re)
+		}
+	}
+	return res, nil
+}
+
+// compilePattern compiles the regular expression given to flag, returning
+// nil for an empty expression.
+func compilePattern(flag, expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", flag, err)
+	}
+	return re, nil
+}
+
+func validatePattern(expr string) error {
+	_, err := regexp.Compile(expr)
+	return err
+}
+
+func validateContext(n int) error {
+	if n < 0 {
+		return fmt.Errorf("context must be 0 or more, got %d", n)
+	}
+	return nil
+}
+
+func validatePatterns(exprs []string) error {
+	for _, expr := range exprs {
+		if err := validatePattern(expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package lx
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// viewString renders rows with their numbers, e.g. "1:a 2:b |... (3 rows skipped)".
+func viewString(rows []row) string {
+	parts := make([]string, len(rows))
+	for i, r := range rows {
+		text := strings.TrimSuffix(string(r.text), "\n")
+		if r.num == 0 {
+			parts[i] = "|" + text
+		} else {
+			parts[i] = strconv.Itoa(r.num) + ":" + text
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestRestrictRows(t *testing.T) {
+	rows := numberRows([]byte("a\nb\nc\nd\n"), 1)
+	tests := []struct {
+		from, to int
+		want     string
+	}{
+		{0, 0, "1:a 2:b 3:c 4:d"},
+		{2, 3, "2:b 3:c"},
+		{3, 0, "3:c 4:d"},
+		{0, 1, "1:a"},
+		{3, 10, "3:c 4:d"},
+		{5, 6, ""},
+	}
+
+	for _, tt := range tests {
+		if got := viewString(restrictRows(rows, tt.from, tt.to)); got != tt.want {
+			t.Errorf("restrictRows(%d, %d) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestSliceRows_SkipsExistingMarkers(t *testing.T) {
+	gap := ellipsis{template: "{from}-{to}"}
+	rows := numberRows([]byte("a\nb\nc\nd\ne\nf\ng\n"), 1)
+	rows = selectSections(rows, regexp.MustCompile("[bf]"), regexp.MustCompile("[cg]"), gap)
+
+	tests := []struct {
+		head, tail int
+		want       string
+	}{
+		{0, 0, "2:b 3:c |4-5 6:f 7:g"},
+		{2, 0, "2:b 3:c"},
+		{3, 0, "2:b 3:c |4-5 6:f"},
+		{0, 2, "6:f 7:g"},
+		{1, 1, "2:b |3-6 7:g"},
+		{2, 2, "2:b 3:c |4-5 6:f 7:g"},
+	}
+
+	for _, tt := range tests {
+		if got := viewString(sliceRows(rows, tt.head, tt.tail, gap)); got != tt.want {
+			t.Errorf("sliceRows(%d, %d) = %q, want %q", tt.head, tt.tail, got, tt.want)
+		}
+	}
+}
+
+func TestSelectSections(t *testing.T) {
+	gap := ellipsis{template: "{from}-{to}"}
+	data := []byte("boot\nstart\nok\npanic\nidle\nstart\nwork\npanic\nidle\n")
+	start := regexp.MustCompile("^start")
+	end := regexp.MustCompile("^panic")
+	marker := regexp.MustCompile("^(start|panic)")
+
+	tests := []struct {
+		name       string
+		start, end *regexp.Regexp
+		want       string
+	}{
+		{"sections", start, end, "2:start 3:ok 4:panic |5-5 6:start 7:work 8:panic"},
+		{"start only", start, nil, "2:start 3:ok 4:panic 5:idle 6:start 7:work 8:panic 9:idle"},
+		{"end only", nil, end, "1:boot 2:start 3:ok 4:panic"},
+		{"same pattern", marker, marker, "2:start 3:ok 4:panic |5-5 6:start 7:work 8:panic"},
+		{"no match", regexp.MustCompile("none"), end, ""},
+	}
+
+	for _, tt := range tests {
+		got := viewString(selectSections(numberRows(data, 1), tt.start, tt.end, gap))
+		if got != tt.want {
+			t.Errorf("%s: selectSections = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchContext(t *testing.T) {
+	gap := ellipsis{template: "{from}-{to}"}
+	data := []byte("a\nb\nERR\nc\nd\ne\nf\nERR\ng\nERR\nh\n")
+	re := regexp.MustCompile("ERR")
+
+	tests := []struct {
+		context int
+		want    string
+	}{
+		{0, "3:ERR |4-7 8:ERR |9-9 10:ERR"},
+		{1, "2:b 3:ERR 4:c |5-6 7:f 8:ERR 9:g 10:ERR 11:h"},
+		{2, "1:a 2:b 3:ERR 4:c 5:d 6:e 7:f 8:ERR 9:g 10:ERR 11:h"},
+		{-1, "3:ERR |4-7 8:ERR |9-9 10:ERR"},
+	}
+
+	for _, tt := range tests {
+		got := viewString(matchContext(numberRows(data, 1), re, tt.context, gap))
+		if got != tt.want {
+			t.Errorf("matchContext(C=%d) = %q, want %q", tt.context, got, tt.want)
+		}
+	}
+}
+
+func TestValidateContext(t *testing.T) {
+	if err := validateContext(0); err != nil {
+		t.Errorf("validateContext(0) error: %v", err)
+	}
+	if err := validateContext(-1); err == nil {
+		t.Errorf("validateContext(-1) succeeded, want error")
+	}
+}
+
+func TestMatchContext_KeepsMarkersInsideWindows(t *testing.T) {
+	gap := ellipsis{template: "{from}-{to}"}
+	rows := numberRows([]byte("a\nb\nc\nd\ne\n"), 1)
+	rows = selectSections(rows, regexp.MustCompile("[ad]"), regexp.MustCompile("[be]"), gap)
+
+	got := viewString(matchContext(rows, regexp.MustCompile("b"), 1, gap))
+	if want := "1:a 2:b |3-3 4:d"; got != want {
+		t.Errorf("matchContext = %q, want %q", got, want)
+	}
+}
+
+func TestFilterRows(t *testing.T) {
+	data := []byte("GET /health\nGET /api\nDEBUG x\nPOST /api\nGET /health\n")
+
+	tests := []struct {
+		name        string
+		keep, drop  *regexp.Regexp
+		want        string
+		wantDropped int
+	}{
+		{"drop", nil, regexp.MustCompile("health|DEBUG"), "2:GET /api 4:POST /api", 3},
+		{"keep", regexp.MustCompile("^GET"), nil, "1:GET /health 2:GET /api 5:GET /health", 2},
+		{"both", regexp.MustCompile("^GET"), regexp.MustCompile("health"), "2:GET /api", 4},
+	}
+
+	for _, tt := range tests {
+		rows, dropped := filterRows(numberRows(data, 1), tt.keep, tt.drop)
+		if got := viewString(rows); got != tt.want || dropped != tt.wantDropped {
+			t.Errorf("%s: filterRows = %q (%d dropped), want %q (%d dropped)",
+				tt.name, got, dropped, tt.want, tt.wantDropped)
+		}
+	}
+}
+
+func TestDedupeRows(t *testing.T) {
+	data := []byte("retry 2024-05-01T10:00:01Z id=3f2a9c1e7b4d\n" +
+		"retry 2024-05-01T10:00:02Z id=9b8e7d6c5a4f\n" +
+		"retry 2024-05-01T10:00:03Z id=0a1b2c3d4e5f\n" +
+		"ok\nok\nfail\n")
+	normalize, err := compilePatterns("--dedupe-normalize", defaultDedupeNormalize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := dedupeRows(numberRows(data, 1), normalize)
+	var got []string
+	for _, r := range rows {
+		got = append(got, strconv.Itoa(r.num)+"x"+strconv.Itoa(max(r.repeats, 1)))
+	}
+	if want := "1x3 4x2 6x1"; strings.Join(got, " ") != want {
+		t.Errorf("dedupeRows = %q, want %q", strings.Join(got, " "), want)
+	}
+
+	exact := dedupeRows(numberRows(data, 1), nil)
+	if len(exact) != 5 {
+		t.Errorf("dedupeRows without normalization kept %d rows, want 5", len(exact))
+	}
+}
+
+func TestAnnotateRepeats(t *testing.T) {
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"retry\n", 1, "retry\n"},
+		{"retry\r\n", 312, "retry (repeated 312 times)\r\n"},
+		{"retry", 2, "retry (repeated 2 times)"},
+	}
+
+	for _, tt := range tests {
+		if got := string(annotateRepeats([]byte(tt.text), tt.n)); got != tt.want {
+			t.Errorf("annotateRepeats(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+	}
+}
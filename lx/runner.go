@@ -1,12 +1,15 @@
 package lx
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,6 +19,156 @@ type Runner struct {
 	PrefixDelimiter  string
 	PostfixDelimiter string
 	LineNumbers      bool
+
+	// LineNumberFormat styles line numbers: "plain" (the default), "padded",
+	// "gutter", "L", "tab", or a template containing {line}.
+	LineNumberFormat string
+
+	// From and To restrict output to a 1-based, inclusive row range before
+	// head/tail slicing is applied. Zero leaves that side open.
+	From int
+	To   int
+
+	// Symbol restricts output to the declaration with this name (Go only),
+	// taking precedence over From/To.
+	Symbol string
+
+	// FromRegex and ToRegex select sections that start at a row matching
+	// FromRegex and run through the next row matching ToRegex. Either may be
+	// empty to leave that side open.
+	FromRegex string
+	ToRegex   string
+
+	// Since and Until keep only log entries timestamped inside the window;
+	// see parseTimeBound for the accepted values.
+	Since string
+	Until string
+
+	// KeepLines and DropLines filter rows after the row range, symbol and
+	// time window, and before deduplication, sections, matches and head/tail
+	// slicing: only rows matching KeepLines and not matching DropLines are
+	// printed. The number of rows removed is available as {dropped_rows}.
+	KeepLines string
+	DropLines string
+
+	// DedupeLines collapses consecutive rows that are identical after
+	// removing the DedupeNormalize patterns (timestamps and IDs by default)
+	// into one row noting "(repeated N times)".
+	DedupeLines     bool
+	DedupeNormalize []string
+
+	// NotebookOutputs includes text outputs, truncated, and placeholders for
+	// image outputs when rendering Jupyter notebooks.
+	NotebookOutputs bool
+
+	// Tables renders CSV and TSV files as Markdown tables of the header and
+	// the head/tail records (a sample from both ends by default), followed
+	// by the row count and inferred column types.
+	Tables bool
+
+	// SummarizeData shortens JSON and YAML documents while keeping them
+	// valid: long arrays keep their first few elements and long strings are
+	// cut. DataSchema appends the structure inferred from JSON documents.
+	SummarizeData bool
+	DataSchema    bool
+
+	// EmbedImages renders images as base64 data URIs instead of a
+	// placeholder giving their format, dimensions and size. SVG files are
+	// shown as XML text unless embedded.
+	EmbedImages bool
+
+	// Match keeps only rows matching this pattern plus Context rows on
+	// either side, like grep -C.
+	Match   string
+	Context int
+
+	// Jobs is the number of files read and rendered concurrently. Output
+	// order always follows the input order.
+	Jobs int
+
+	// CommandTimeout stops a command entry that runs longer, failing it like
+	// an unreadable file. Zero means no limit.
+	CommandTimeout time.Duration
+
+	// KeepGoing renders an error placeholder for files that cannot be read
+	// and continues with the rest; SkipErrors omits the placeholder.
+	KeepGoing  bool
+	SkipErrors bool
+
+	// EOL selects the line ending for delimiters and content: "native"
+	// (the default), "lf", "crlf" or "preserve".
+	EOL string
+
+	// Ellipsis is the template for the marker that replaces rows skipped
+	// between head and tail, with {skipped}, {from}, {to} and {n}
+	// placeholders. Empty uses "... ({skipped} rows skipped)".
+	Ellipsis string
+
+	// MaxLineLength truncates longer lines, counted in characters, with a
+	// marker giving the number of characters cut. Zero means no limit.
+	MaxLineLength int
+
+	// Language forces the fence language instead of detecting it.
+	Language string
+
+	// Languages maps lowercased extensions (".tpl") and file names
+	// ("jenkinsfile.ci") to fence languages, taking precedence over the
+	// built-in tables.
+	Languages map[string]string
+}
+
+// FileError records a file that failed to render under KeepGoing.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+// PartialError is returned when KeepGoing let a run finish despite failing
+// files; the output for every other file was still written.
+type PartialError struct {
+	Failed []FileError
+	Total  int
+}
+
+// partialExitCode is the exit status when some, but not all, files failed.
+const partialExitCode = 3
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%d of %d files failed", len(e.Failed), e.Total)
+}
+
+// exitCode distinguishes partial success (3) from every file failing (1).
+func (e *PartialError) exitCode() int {
+	if len(e.Failed) >= e.Total {
+		return 1
+	}
+	return partialExitCode
+}
+
+// Entry pairs a path with an optional Runner override, letting manifests
+// render each entry with its own options, and an optional fence language
+// given as "path::lang". Entries with a Command render the output of that
+// shell command instead of a file.
+type Entry struct {
+	Path     string
+	Runner   *Runner
+	Language string
+	Command  string
+}
+
+// name identifies the entry in output and error reports.
+func (e Entry) name() string {
+	if e.Command != "" {
+		return "$ " + e.Command
+	}
+	return e.Path
+}
+
+// ParseEntry makes an entry from a path argument, which may name its fence
+// language as "path::lang".
+func ParseEntry(arg string) Entry {
+	path, lang := splitLanguage(arg)
+	return Entry{Path: path, Language: lang}
 }
 
 // platform-specific newline placeholder replacement
@@ -29,7 +182,7 @@ var nl = func() string {
 // NewRunner constructs a Runner with default delimiters if none are provided.
 func NewRunner(head, tail int, prefix, postfix string, lineNumbers bool) Runner {
 	if prefix == "" {
-		prefix = "{filename} ({row_count} rows){n}---{n}```{language}{n}"
+		prefix = "{filename} ({row_count} rows{notes}){n}---{n}```{language}{n}"
 	}
 	if postfix == "" {
 		postfix = "```{n}{n}"
@@ -43,66 +196,556 @@ func NewRunner(head, tail int, prefix, postfix string, lineNumbers bool) Runner
 	}
 }
 
-func (r Runner) buildPrefix(path string, totalRows int, byteSize int64, lastMod, lang string) string {
+// header carries the values substituted into prefix placeholders.
+type header struct {
+	path        string
+	totalRows   int
+	droppedRows int
+	byteSize    int64
+	lastMod     string
+	language    string
+	encoding    string
+	lineEndings string
+	minified    bool
+	// unsummarized is set for data files shown as-is because they could
+	// not be parsed for SummarizeData.
+	unsummarized bool
+}
+
+func (r Runner) buildPrefix(h header) string {
 	prefix := r.PrefixDelimiter
-	prefix = strings.ReplaceAll(prefix, "{filename}", path)
-	prefix = strings.ReplaceAll(prefix, "{row_count}", strconv.Itoa(totalRows))
-	prefix = strings.ReplaceAll(prefix, "{byte_size}", strconv.FormatInt(byteSize, 10))
-	prefix = strings.ReplaceAll(prefix, "{last_modified}", lastMod)
-	prefix = strings.ReplaceAll(prefix, "{language}", lang)
-	prefix = strings.ReplaceAll(prefix, "{n}", nl)
+	prefix = strings.ReplaceAll(prefix, "{filename}", h.path)
+	prefix = strings.ReplaceAll(prefix, "{row_count}", strconv.Itoa(h.totalRows))
+	prefix = strings.ReplaceAll(prefix, "{dropped_rows}", strconv.Itoa(h.droppedRows))
+	prefix = strings.ReplaceAll(prefix, "{byte_size}", strconv.FormatInt(h.byteSize, 10))
+	prefix = strings.ReplaceAll(prefix, "{last_modified}", h.lastMod)
+	prefix = strings.ReplaceAll(prefix, "{language}", h.language)
+	prefix = strings.ReplaceAll(prefix, "{encoding}", h.encoding)
+	prefix = strings.ReplaceAll(prefix, "{line_endings}", h.lineEndings)
+	var minified, notes string
+	if h.minified {
+		minified, notes = "minified", ", minified"
+	}
+	if h.unsummarized {
+		notes += ", not summarized"
+	}
+	prefix = strings.ReplaceAll(prefix, "{minified}", minified)
+	prefix = strings.ReplaceAll(prefix, "{notes}", notes)
+	prefix = strings.ReplaceAll(prefix, "{n}", r.newline())
 	return prefix
 }
 
 func (r Runner) buildPostfix() string {
-	return strings.ReplaceAll(r.PostfixDelimiter, "{n}", nl)
+	return strings.ReplaceAll(r.PostfixDelimiter, "{n}", r.newline())
+}
+
+// openFile opens files for reading. Benchmarks replace it to simulate slow
+// file systems such as network mounts.
+var openFile = os.Open
+
+// readFile reads a whole file through openFile.
+func readFile(path string) ([]byte, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	size := 0
+	if info, err := f.Stat(); err == nil {
+		size = int(info.Size())
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, size+bytes.MinRead))
+	if _, err := buf.ReadFrom(f); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fileView is the part of a file selected for output.
+type fileView struct {
+	rows      []row  // selected rows, including any gap markers
+	totalRows int    // rows in the whole file, or in its rendering
+	language  string // fence language, see detectLanguage
+	dropped   int    // rows removed by KeepLines/DropLines
+	encoding  string // encoding the file was transcoded to UTF-8 from
+	endings   string // line endings used by the file, see lineEndings
+	minified  bool   // whether the file looks minified, see looksMinified
+	// unsummarized is set when SummarizeData could not parse the file.
+	unsummarized bool
+	numbered     bool // whether the rows already show their line numbers
+}
+
+// streamable reports whether the file can be sliced by streaming it rather
+// than reading it into memory. Unsliced output needs the whole file anyway,
+// and ranges, symbols and patterns are resolved in memory.
+func (r Runner) streamable() bool {
+	return (r.Head > 0 || r.Tail > 0) && r.From == 0 && r.To == 0 && r.Symbol == "" &&
+		r.FromRegex == "" && r.ToRegex == "" && r.Match == "" &&
+		r.KeepLines == "" && r.DropLines == "" && !r.DedupeLines &&
+		r.Since == "" && r.Until == ""
+}
+
+// renderFunc turns the decoded content of a structured file into the text
+// shown for it, along with the fence language ("" to derive it from the
+// path).
+type renderFunc func(data []byte) ([]byte, string, error)
+
+// renderer returns how the file at path is rendered, or nil to show its
+// content as-is.
+func (r Runner) renderer(path string) renderFunc {
+	switch {
+	case isNotebook(path):
+		return func(data []byte) ([]byte, string, error) {
+			return renderNotebook(data, r.NotebookOutputs)
+		}
+	case r.SummarizeData && isDataFile(path):
+		return func(data []byte) ([]byte, string, error) {
+			out, lang, err := summarizeData(data, path, r.DataSchema)
+			if err != nil {
+				return nil, "", fmt.Errorf("%w: %w", errNotSummarized, err)
+			}
+			return out, lang, nil
+		}
+	}
+	return nil
+}
+
+func (r Runner) readView(path string) (fileView, error) {
+	gap := ellipsis{template: r.Ellipsis}
+	render := r.renderer(path)
+	table := r.Tables && isTable(path)
+	mime := imageType(path, r.EmbedImages)
+	if r.streamable() && render == nil && !table && mime == "" {
+		v, ok, err := streamFile(path, r.Head, r.Tail, gap, r.Languages)
+		if err != nil {
+			return fileView{}, fmt.Errorf("read %q: %w", path, err)
+		}
+		if ok {
+			return v, nil
+		}
+	}
+
+	raw, err := readFile(path)
+	if err != nil {
+		return fileView{}, fmt.Errorf("read %q: %w", path, err)
+	}
+	if mime != "" {
+		rows, err := renderImage(raw, mime, r.EmbedImages)
+		if err != nil {
+			return fileView{}, fmt.Errorf("%q: %w", path, err)
+		}
+		return fileView{rows: rows, totalRows: len(rows), language: "text"}, nil
+	}
+	data, enc := decodeText(raw)
+	endings := lineEndings(data)
+
+	if table {
+		// Tables show a sample from both ends unless sliced otherwise.
+		sel := r
+		if sel.Head <= 0 && sel.Tail <= 0 {
+			sel.Head, sel.Tail = tableSampleRows, tableSampleRows
+		}
+		var dropped int
+		rows, err := renderTable(data, path, r.LineNumbers, func(records []row) ([]row, error) {
+			v, err := sel.selectRows(path, data, records)
+			dropped = v.dropped
+			return v.rows, err
+		})
+		if err != nil {
+			return fileView{}, fmt.Errorf("%q: %w", path, err)
+		}
+		return fileView{
+			rows:      rows,
+			totalRows: countLines(data),
+			dropped:   dropped,
+			language:  "markdown",
+			encoding:  enc,
+			endings:   endings,
+			numbered:  r.LineNumbers,
+		}, nil
+	}
+	var (
+		lang         string
+		unsummarized bool
+	)
+	if render != nil {
+		rendered, l, err := render(data)
+		switch {
+		case errors.Is(err, errNotSummarized):
+			// Summarizing only shortens the output, so a document that
+			// doesn't parse, such as JSON with comments, is shown as is.
+			unsummarized = true
+		case err != nil:
+			return fileView{}, fmt.Errorf("%q: %w", path, err)
+		default:
+			data, lang = rendered, l
+		}
+	}
+	if lang == "" {
+		// Only the ends of the file are inspected, as when streaming.
+		head := data[:min(len(data), encodingSniffSize)]
+		tail := data[max(len(data)-encodingSniffSize, 0):]
+		lang = detectLanguage(path, head, tail, r.Languages)
+	}
+
+	v, err := r.selectView(path, data)
+	if err != nil {
+		return fileView{}, err
+	}
+	v.language = lang
+	v.encoding = enc
+	v.endings = endings
+	v.unsummarized = unsummarized
+	return v, nil
 }
 
-func (r Runner) runFile(path string, out io.Writer) error {
+// selectView numbers the rows of data and applies the row selection
+// options: the range or symbol, time window, line filters, deduplication,
+// sections, matches and head/tail slicing, in that order.
+func (r Runner) selectView(path string, data []byte) (fileView, error) {
+	return r.selectRows(path, data, numberRows(data, 1))
+}
+
+// selectRows is selectView on rows already split from data, such as the
+// records of a table.
+func (r Runner) selectRows(path string, data []byte, rows []row) (fileView, error) {
+	gap := ellipsis{template: r.Ellipsis}
+	start, err := compilePattern("--from-regex", r.FromRegex)
+	if err != nil {
+		return fileView{}, err
+	}
+	end, err := compilePattern("--to-regex", r.ToRegex)
+	if err != nil {
+		return fileView{}, err
+	}
+	match, err := compilePattern("--match", r.Match)
+	if err != nil {
+		return fileView{}, err
+	}
+	keep, err := compilePattern("--keep-lines", r.KeepLines)
+	if err != nil {
+		return fileView{}, err
+	}
+	drop, err := compilePattern("--drop-lines", r.DropLines)
+	if err != nil {
+		return fileView{}, err
+	}
+	now := time.Now()
+	window, err := parseTimeWindow(r.Since, r.Until, now)
+	if err != nil {
+		return fileView{}, err
+	}
+	normalize := r.DedupeNormalize
+	if normalize == nil {
+		normalize = defaultDedupeNormalize
+	}
+	dedupe, err := compilePatterns("--dedupe-normalize", normalize)
+	if err != nil {
+		return fileView{}, err
+	}
+
+	total := len(rows)
+	longest := longestRow(rows)
+
+	from, to := r.From, r.To
+	if r.Symbol != "" {
+		from, to, err = findSymbol(path, data, r.Symbol)
+		if err != nil {
+			return fileView{}, fmt.Errorf("%q: %w", path, err)
+		}
+	}
+	if from > 0 || to > 0 {
+		rows = restrictRows(rows, from, to)
+	}
+	if window != nil {
+		rows = window.filter(rows, gap, now)
+	}
+	var dropped int
+	if keep != nil || drop != nil {
+		rows, dropped = filterRows(rows, keep, drop)
+	}
+	if r.DedupeLines {
+		rows = dedupeRows(rows, dedupe)
+	}
+	if start != nil || end != nil {
+		rows = selectSections(rows, start, end, gap)
+	}
+	if match != nil {
+		rows = matchContext(rows, match, r.Context, gap)
+	}
+
+	return fileView{
+		rows:      sliceRows(rows, r.Head, r.Tail, gap),
+		totalRows: total,
+		dropped:   dropped,
+		minified:  looksMinified(int64(len(data)), total, longest),
+	}, nil
+}
+
+// outputBlock is the rendered output of one entry. The delimiters are kept
+// apart from the body so that a block too large for one part of split output
+// can be cut with its delimiters around every piece.
+type outputBlock struct {
+	prefix, body, postfix []byte
+	// continued is the prefix of the pieces after the first.
+	continued []byte
+	// rows is the number of content rows in the body, and rowStarts the
+	// offsets in body at which they start, so that pieces of a block cut by
+	// splitParts can count theirs without the gap markers.
+	rows      int
+	rowStarts []int
+}
+
+// bytes returns the block as written.
+func (b outputBlock) bytes() []byte {
+	out := make([]byte, 0, len(b.prefix)+len(b.body)+len(b.postfix))
+	out = append(out, b.prefix...)
+	out = append(out, b.body...)
+	return append(out, b.postfix...)
+}
+
+func joinBlocks(blocks []outputBlock) []byte {
+	var out []byte
+	for _, b := range blocks {
+		out = append(out, b.bytes()...)
+	}
+	return out
+}
+
+func (r Runner) runFile(path string) (outputBlock, error) {
 	info, err := os.Stat(path)
 	if err != nil {
-		return fmt.Errorf("stat %q: %w", path, err)
+		return outputBlock{}, fmt.Errorf("stat %q: %w", path, err)
 	}
 
-	data, err := os.ReadFile(path)
+	v, err := r.readView(path)
 	if err != nil {
-		return fmt.Errorf("read %q: %w", path, err)
+		return outputBlock{}, err
 	}
 
-	view, totalRows := prepareView(data, r.Head, r.Tail)
+	return r.renderView(v, header{
+		path:     path,
+		byteSize: info.Size(),
+		lastMod:  info.ModTime().Format(time.RFC3339),
+	})
+}
 
-	byteSize := info.Size()
-	lastMod := info.ModTime().Format(time.RFC3339)
-	lang := languageFromPath(path)
+// renderView renders v between the prefix and postfix delimiters, filling
+// in the header fields that come from the view itself.
+func (r Runner) renderView(v fileView, h header) (outputBlock, error) {
+	format, err := parseLineNumberFormat(r.LineNumberFormat)
+	if err != nil {
+		return outputBlock{}, err
+	}
 
-	prefix := r.buildPrefix(path, totalRows, byteSize, lastMod, lang)
+	h.totalRows = v.totalRows
+	h.droppedRows = v.dropped
+	h.language = v.language
+	if r.Language != "" {
+		h.language = r.Language
+	}
+	h.encoding = v.encoding
+	h.lineEndings = v.endings
+	h.minified = v.minified
+	h.unsummarized = v.unsummarized
 
-	if _, err := out.Write([]byte(prefix)); err != nil {
-		return fmt.Errorf("write prefix: %w", err)
+	rows := v.rows
+	for i, rw := range rows {
+		if r.EOL != EOLPreserve {
+			rows[i].text = normalizeEOL(rw.text, r.newline())
+		}
+		if rw.num > 0 {
+			rows[i].text = truncateLines(rows[i].text, r.MaxLineLength)
+			rows[i].text = annotateRepeats(rows[i].text, rw.repeats)
+		}
 	}
 
-	var toWrite []byte
-	if r.LineNumbers {
-		toWrite = addLineNumbers(view, totalRows, r.Head, r.Tail)
+	var (
+		body   []byte
+		starts []int
+	)
+	if r.LineNumbers && !v.numbered {
+		body, starts = addLineNumbers(rows, format, v.totalRows)
 	} else {
-		toWrite = view
+		body, starts = joinRows(rows), rowStarts(rows)
+	}
+
+	b := outputBlock{
+		prefix:    []byte(r.buildPrefix(h)),
+		body:      body,
+		postfix:   []byte(r.buildPostfix()),
+		rows:      len(starts),
+		rowStarts: starts,
+	}
+	h.path += " (continued)"
+	b.continued = []byte(r.buildPrefix(h))
+	return b, nil
+}
+
+func (r Runner) Run(files []string, out io.Writer) error {
+	entries := make([]Entry, len(files))
+	for i, path := range files {
+		entries[i] = ParseEntry(path)
+	}
+	return r.RunEntries(entries, out)
+}
+
+// RunEntries renders entries in order, using each entry's Runner when set and
+// r otherwise.
+func (r Runner) RunEntries(entries []Entry, out io.Writer) error {
+	return r.renderEach(entries, func(block outputBlock) error {
+		if _, err := out.Write(block.bytes()); err != nil {
+			return fmt.Errorf("lx: write output: %w", err)
+		}
+		return nil
+	})
+}
+
+// renderEntries renders each entry into its own block, in order. A
+// *PartialError is returned together with the blocks that were rendered.
+func (r Runner) renderEntries(entries []Entry) ([]outputBlock, error) {
+	blocks := make([]outputBlock, 0, len(entries))
+	err := r.renderEach(entries, func(block outputBlock) error {
+		blocks = append(blocks, block)
+		return nil
+	})
+	var partial *PartialError
+	if err != nil && !errors.As(err, &partial) {
+		return nil, err
 	}
+	return blocks, err
+}
 
-	if _, err := out.Write(toWrite); err != nil {
-		return fmt.Errorf("write data: %w", err)
+// renderEach renders entries using up to r.Jobs workers and passes each block
+// to emit in input order, as soon as it and every block before it are ready.
+// Under KeepGoing, files that fail are replaced by an error placeholder (or
+// skipped) and reported together in a *PartialError once all are done.
+// Commands run one at a time, since they may depend on or compete with each
+// other.
+func (r Runner) renderEach(entries []Entry, emit func(block outputBlock) error) error {
+	var commandMu sync.Mutex
+	render := func(e Entry) (outputBlock, error) {
+		if e.Command != "" {
+			commandMu.Lock()
+			defer commandMu.Unlock()
+			return r.forEntry(e).runCommand(e.Command)
+		}
+		return r.forEntry(e).runFile(e.Path)
 	}
-	if _, err := out.Write([]byte(r.buildPostfix())); err != nil {
-		return fmt.Errorf("write postfix: %w", err)
+
+	var failed []FileError
+	handle := func(e Entry, block outputBlock, err error) error {
+		if err != nil {
+			if !r.KeepGoing {
+				return fmt.Errorf("lx: %w", err)
+			}
+			failed = append(failed, FileError{Path: e.name(), Err: err})
+			if r.SkipErrors {
+				return nil
+			}
+			block = r.forEntry(e).errorBlock(e.name(), err)
+		}
+		return emit(block)
 	}
 
+	if err := r.renderOrdered(entries, render, handle); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return &PartialError{Failed: failed, Total: len(entries)}
+	}
 	return nil
 }
 
-func (r Runner) Run(files []string, out io.Writer) error {
-	for _, path := range files {
-		if err := r.runFile(path, out); err != nil {
-			return fmt.Errorf("lx: %w", err)
+// renderOrdered runs render over entries with up to r.Jobs workers and calls
+// handle with each result in input order. It stops at the first error
+// returned by handle.
+func (r Runner) renderOrdered(
+	entries []Entry,
+	render func(Entry) (outputBlock, error),
+	handle func(Entry, outputBlock, error) error,
+) error {
+	jobs := min(r.Jobs, len(entries))
+	if jobs <= 1 {
+		for _, e := range entries {
+			block, err := render(e)
+			if err := handle(e, block, err); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	type result struct {
+		block outputBlock
+		err   error
+	}
+	results := make([]chan result, len(entries))
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+
+	// window bounds how far workers may run ahead of handle, so a slow file
+	// early in the list doesn't buffer the rest of the output in memory.
+	window := make(chan struct{}, 4*jobs)
+	done := make(chan struct{})
+	defer close(done)
+
+	work := make(chan int)
+	go func() {
+		defer close(work)
+		for i := range entries {
+			select {
+			case window <- struct{}{}:
+			case <-done:
+				return
+			}
+			select {
+			case work <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for range jobs {
+		go func() {
+			for i := range work {
+				block, err := render(entries[i])
+				results[i] <- result{block: block, err: err}
+			}
+		}()
+	}
+
+	for i, e := range entries {
+		res := <-results[i]
+		<-window
+		if err := handle(e, res.block, res.err); err != nil {
+			return err
 		}
 	}
 	return nil
 }
+
+// errorBlock is the placeholder rendered under KeepGoing for a file that
+// could not be read.
+func (r Runner) errorBlock(path string, err error) outputBlock {
+	prefix := []byte(r.buildPrefix(header{path: path}))
+	return outputBlock{
+		prefix:    prefix,
+		body:      []byte("[lx: " + err.Error() + "]" + r.newline()),
+		postfix:   []byte(r.buildPostfix()),
+		continued: prefix,
+	}
+}
+
+func (r Runner) forEntry(e Entry) Runner {
+	if e.Runner != nil {
+		r = *e.Runner
+	}
+	if e.Language != "" {
+		r.Language = e.Language
+	}
+	return r
+}
@@ -2,10 +2,14 @@ package lx
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestRunner_DefaultDelimitersAndPlaceholders(t *testing.T) {
@@ -135,3 +139,379 @@ func TestRunner_FileNotFound(t *testing.T) {
 		t.Errorf("error does not mention filename: %v", err)
 	}
 }
+
+func writeTestFiles(tb testing.TB, n, rows int) []string {
+	tb.Helper()
+	dir := tb.TempDir()
+	content := []byte(strings.Repeat("some content on a line\n", rows))
+
+	paths := make([]string, n)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("f%03d.txt", i))
+		if err := os.WriteFile(paths[i], content, 0o644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return paths
+}
+
+func TestRunner_JobsPreserveOrder(t *testing.T) {
+	paths := writeTestFiles(t, 50, 3)
+
+	var sequential, concurrent bytes.Buffer
+	r := NewRunner(0, 0, "", "", true)
+	if err := r.Run(paths, &sequential); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	r.Jobs = 8
+	if err := r.Run(paths, &concurrent); err != nil {
+		t.Fatalf("Run with jobs error: %v", err)
+	}
+
+	if sequential.String() != concurrent.String() {
+		t.Errorf("concurrent output differs from sequential output")
+	}
+}
+
+func TestRunner_JobsReportFirstError(t *testing.T) {
+	paths := writeTestFiles(t, 20, 1)
+	paths[5] = "no_such_file.txt"
+
+	var buf bytes.Buffer
+	r := NewRunner(0, 0, "", "", false)
+	r.Jobs = 4
+	err := r.Run(paths, &buf)
+	if err == nil || !strings.Contains(err.Error(), "no_such_file.txt") {
+		t.Fatalf("expected error mentioning missing file, got %v", err)
+	}
+	if got := strings.Count(buf.String(), "```text"); got != 5 {
+		t.Errorf("wrote %d files before the error, want 5", got)
+	}
+}
+
+func BenchmarkRunner_Jobs(b *testing.B) {
+	paths := writeTestFiles(b, 200, 2000)
+	r := NewRunner(0, 0, "", "", true)
+
+	for _, jobs := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			r.Jobs = jobs
+			for b.Loop() {
+				if err := r.Run(paths, io.Discard); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkRunner_JobsSlowFS simulates a network mount where every open
+// takes a millisecond, which is where concurrent reading pays off.
+func BenchmarkRunner_JobsSlowFS(b *testing.B) {
+	paths := writeTestFiles(b, 100, 50)
+	r := NewRunner(0, 0, "", "", false)
+
+	orig := openFile
+	openFile = func(name string) (*os.File, error) {
+		time.Sleep(time.Millisecond)
+		return os.Open(name)
+	}
+	defer func() { openFile = orig }()
+
+	for _, jobs := range []int{1, 8, 32} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			r.Jobs = jobs
+			for b.Loop() {
+				if err := r.Run(paths, io.Discard); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestRunner_KeepGoingRendersPlaceholder(t *testing.T) {
+	paths := writeTestFiles(t, 3, 1)
+	paths[1] = "no_such_file.txt"
+
+	for _, jobs := range []int{1, 4} {
+		var buf bytes.Buffer
+		r := NewRunner(0, 0, "# {filename}{n}", "{n}", false)
+		r.KeepGoing = true
+		r.Jobs = jobs
+
+		err := r.Run(paths, &buf)
+		var partial *PartialError
+		if !errors.As(err, &partial) {
+			t.Fatalf("jobs %d: expected *PartialError, got %v", jobs, err)
+		}
+		if len(partial.Failed) != 1 || partial.Failed[0].Path != "no_such_file.txt" || partial.Total != 3 {
+			t.Errorf("jobs %d: PartialError = %+v", jobs, partial)
+		}
+		if partial.exitCode() != partialExitCode {
+			t.Errorf("jobs %d: exitCode = %d, want %d", jobs, partial.exitCode(), partialExitCode)
+		}
+
+		out := buf.String()
+		if strings.Count(out, "some content on a line") != 2 {
+			t.Errorf("jobs %d: readable files missing from output:\n%s", jobs, out)
+		}
+		placeholder := "# no_such_file.txt\n[lx: stat \"no_such_file.txt\":"
+		if !strings.Contains(out, placeholder) {
+			t.Errorf("jobs %d: missing placeholder %q in:\n%s", jobs, placeholder, out)
+		}
+		if strings.Index(out, placeholder) > strings.Index(out, paths[2]) {
+			t.Errorf("jobs %d: placeholder out of order:\n%s", jobs, out)
+		}
+	}
+}
+
+func TestRunner_SkipErrorsOmitsFailedFiles(t *testing.T) {
+	var buf bytes.Buffer
+	r := Options{SkipErrors: true}.Effective()
+
+	err := r.Run([]string{"missing1.txt", "missing2.txt"}, &buf)
+	var partial *PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected *PartialError, got %v", err)
+	}
+	if partial.exitCode() != 1 {
+		t.Errorf("exitCode = %d, want 1 when every file failed", partial.exitCode())
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
+
+func TestRunner_MaxLineLengthAndMinified(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.min.js")
+	content := "/*! v1 */\n" + strings.Repeat("x", 6000) + "\nend\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The streamed and in-memory paths must agree.
+	for _, tail := range []int{0, 2} {
+		var buf bytes.Buffer
+		r := NewRunner(0, tail, "[{minified}]{n}", "", true)
+		r.MaxLineLength = 10
+		r.EOL = EOLLF
+		if err := r.Run([]string{path}, &buf); err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+
+		want := "[minified]\n"
+		if tail == 0 {
+			want += "1: /*! v1 */\n"
+		}
+		want += "2: xxxxxxxxxx… (+5990 chars)\n3: end\n```\n\n"
+		if buf.String() != want {
+			t.Errorf("tail %d: output = %q, want %q", tail, buf.String(), want)
+		}
+
+		// The default prefix notes it too.
+		buf.Reset()
+		r = NewRunner(0, tail, "", "", false)
+		r.MaxLineLength = 10
+		if err := r.Run([]string{path}, &buf); err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+		if want := path + " (3 rows, minified)"; !strings.HasPrefix(buf.String(), want) {
+			t.Errorf("tail %d: default prefix = %q, want %q", tail, buf.String(), want)
+		}
+	}
+}
+
+func TestRunner_EllipsisTemplate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	var content strings.Builder
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		from, to int
+		template string
+		want     string
+	}{
+		{
+			// Streamed.
+			name:     "whole file",
+			template: "// lines {from}-{to} omitted",
+			want:     " 1: line 1\n// lines 2-19 omitted\n20: line 20\n",
+		},
+		{
+			name: "range", from: 5, to: 15,
+			template: "/*{n}{skipped} rows{n}*/",
+			want:     " 5: line 5\n/*\n9 rows\n*/\n15: line 15\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		r := NewRunner(1, 1, "{n}", "{n}", true)
+		r.From, r.To = tt.from, tt.to
+		r.Ellipsis = tt.template
+		r.LineNumberFormat = LineNumbersPadded
+		r.EOL = EOLLF
+		if err := r.Run([]string{path}, &buf); err != nil {
+			t.Fatalf("%s: Run error: %v", tt.name, err)
+		}
+		if want := "\n" + tt.want + "\n"; buf.String() != want {
+			t.Errorf("%s: output = %q, want %q", tt.name, buf.String(), want)
+		}
+	}
+}
+
+func TestRunner_RegexSections(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.log")
+	content := "boot\nStarting server\nok\npanic: a\nretry\nStarting server\nserving\npanic: b\nexit\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		head int
+		tail int
+		want string
+	}{
+		{"all sections", 0, 0, "2: Starting server\n3: ok\n4: panic: a\n... (1 rows skipped)\n" +
+			"6: Starting server\n7: serving\n8: panic: b\n"},
+		{"last section", 0, 3, "6: Starting server\n7: serving\n8: panic: b\n"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		r := NewRunner(tt.head, tt.tail, "{n}", "{n}", true)
+		r.FromRegex = "^Starting server"
+		r.ToRegex = "^panic"
+		r.EOL = EOLLF
+		if err := r.Run([]string{path}, &buf); err != nil {
+			t.Fatalf("%s: Run error: %v", tt.name, err)
+		}
+		if want := "\n" + tt.want + "\n"; buf.String() != want {
+			t.Errorf("%s: output = %q, want %q", tt.name, buf.String(), want)
+		}
+	}
+}
+
+func TestRunner_DropLinesBeforeSlicing(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	content := "start\nDEBUG a\nwork\nDEBUG b\nDEBUG c\ndone\nDEBUG d\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	r := NewRunner(0, 2, "{row_count} rows, {dropped_rows} dropped{n}", "{n}", true)
+	r.DropLines = "DEBUG"
+	r.EOL = EOLLF
+	if err := r.Run([]string{path}, &buf); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+
+	want := "7 rows, 4 dropped\n3: work\n6: done\n\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRunner_DedupeLines(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	var content strings.Builder
+	content.WriteString("start\n")
+	for i := range 312 {
+		fmt.Fprintf(&content, "10:00:%02d connection refused, retrying\n", i%60)
+	}
+	content.WriteString("giving up\n")
+	if err := os.WriteFile(path, []byte(content.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	r := NewRunner(0, 2, "{n}", "{n}", true)
+	r.DedupeLines = true
+	r.EOL = EOLLF
+	if err := r.Run([]string{path}, &buf); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+
+	want := "\n2: 10:00:00 connection refused, retrying (repeated 312 times)\n314: giving up\n\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRunner_ImagePlaceholder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pixel.gif")
+	// A 1×1 GIF.
+	gif := "GIF89a\x01\x00\x01\x00\x80\x00\x00\x00\x00\x00\xff\xff\xff,\x00\x00\x00\x00\x01\x00\x01\x00\x00\x02\x02D\x01\x00;"
+	if err := os.WriteFile(path, []byte(gif), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	r := NewRunner(1, 0, "```{language}{n}", "```{n}", true)
+	r.EOL = EOLLF
+	if err := r.Run([]string{path}, &buf); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+
+	want := "```text\n[image: gif, 1×1, 35 bytes]\n```\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRunner_LanguageFromShebang(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "deploy")
+	if err := os.WriteFile(path, []byte("#!/usr/bin/env bash\necho one\necho two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sliced views are streamed, and must detect the language too.
+	for _, head := range []int{0, 1} {
+		var buf bytes.Buffer
+		r := NewRunner(head, 0, "{language}{n}", "{n}", false)
+		r.EOL = EOLLF
+		if err := r.Run([]string{path}, &buf); err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+		if got, _, _ := strings.Cut(buf.String(), "\n"); got != "bash" {
+			t.Errorf("head %d: language = %q, want bash", head, got)
+		}
+	}
+}
+
+func TestRunner_LanguageOverride(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "page.tpl")
+	if err := os.WriteFile(path, []byte("{{ .Title }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	r := NewRunner(0, 0, "{language}{n}", "{n}", false)
+	r.EOL = EOLLF
+	r.Languages = map[string]string{".tpl": "html"}
+	if err := r.Run([]string{path, path + "::gotemplate"}, &buf); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+
+	want := "html\n{{ .Title }}\n\ngotemplate\n{{ .Title }}\n\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
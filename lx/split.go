@@ -0,0 +1,142 @@
+package lx
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// outputPart is one message-sized piece of split output.
+type outputPart struct {
+	data  []byte
+	files int
+	rows  int // content rows, see outputBlock
+}
+
+func partHeader(i, n int, newline string) string {
+	return fmt.Sprintf("Part %d/%d%s%s", i, n, newline, newline)
+}
+
+// splitParts packs rendered blocks into parts of at most limit bytes, each
+// introduced by a "Part i/n" header. Blocks are kept whole unless a single
+// block exceeds the limit, in which case its body is cut at line boundaries
+// and every piece gets the block's delimiters, so that no code fence spans
+// parts; pieces after the first name the file as continued. Headers and cut
+// lines end in newline. If everything fits in one part it is returned as-is,
+// without a header.
+func splitParts(blocks []outputBlock, limit int, newline string) []outputPart {
+	if whole := wholePart(blocks); len(whole.data) <= limit {
+		return []outputPart{whole}
+	}
+
+	// Reserve room for the header added to every part.
+	budget := max(limit-len(partHeader(999, 999, newline)), 1)
+
+	var (
+		parts []outputPart
+		cur   outputPart
+	)
+	flush := func() {
+		if len(cur.data) > 0 {
+			parts = append(parts, cur)
+		}
+		cur = outputPart{}
+	}
+
+	for _, b := range blocks {
+		data := b.bytes()
+		if len(cur.data)+len(data) <= budget {
+			cur.data = append(cur.data, data...)
+			cur.files++
+			cur.rows += b.rows
+			continue
+		}
+		flush()
+
+		if len(data) <= budget {
+			cur = outputPart{data: data, files: 1, rows: b.rows}
+			continue
+		}
+
+		// A newline more ends pieces of a line cut for being too long.
+		delims := max(len(b.prefix), len(b.continued)) + len(newline) + len(b.postfix)
+		chunks := splitAtLines(b.body, max(budget-delims, 1))
+		pieces := make([]outputPart, len(chunks))
+		offset, next := 0, 0 // next indexes b.rowStarts
+		for i, c := range chunks {
+			// Count the content rows starting within this chunk.
+			offset += len(c)
+			rows := 0
+			for ; next < len(b.rowStarts) && b.rowStarts[next] < offset; next++ {
+				rows++
+			}
+
+			prefix := b.continued
+			if i == 0 {
+				prefix = b.prefix
+			}
+			piece := append(append([]byte(nil), prefix...), c...)
+			if !bytes.HasSuffix(c, []byte("\n")) {
+				piece = append(piece, newline...)
+			}
+			pieces[i] = outputPart{data: append(piece, b.postfix...), files: 1, rows: rows}
+		}
+		parts = append(parts, pieces[:len(pieces)-1]...)
+		// The last piece of an oversized block can share a part with the
+		// blocks that follow it.
+		cur = pieces[len(pieces)-1]
+	}
+	flush()
+
+	for i := range parts {
+		header := partHeader(i+1, len(parts), newline)
+		parts[i].data = append([]byte(header), parts[i].data...)
+	}
+	return parts
+}
+
+// wholePart joins blocks into a single part.
+func wholePart(blocks []outputBlock) outputPart {
+	p := outputPart{data: joinBlocks(blocks), files: len(blocks)}
+	for _, b := range blocks {
+		p.rows += b.rows
+	}
+	return p
+}
+
+// splitAtLines cuts data into chunks of at most limit bytes, preferring line
+// boundaries and falling back to rune boundaries for lines that are longer
+// than limit on their own.
+func splitAtLines(data []byte, limit int) [][]byte {
+	var (
+		chunks [][]byte
+		cur    []byte
+	)
+
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if len(cur)+len(line) <= limit {
+			cur = append(cur, line...)
+			continue
+		}
+		if len(cur) > 0 {
+			chunks = append(chunks, cur)
+			cur = nil
+		}
+		for len(line) > limit {
+			cut := limit
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			if cut == 0 {
+				cut = limit
+			}
+			chunks = append(chunks, append([]byte(nil), line[:cut]...))
+			line = line[cut:]
+		}
+		cur = append(cur, line...)
+	}
+	if len(cur) > 0 || len(chunks) == 0 {
+		chunks = append(chunks, cur)
+	}
+	return chunks
+}
@@ -0,0 +1,166 @@
+package lx
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitParts_FitsInOnePart(t *testing.T) {
+	blocks := []outputBlock{{body: []byte("a\n")}, {body: []byte("b\n")}}
+	parts := splitParts(blocks, 100, "\n")
+	if len(parts) != 1 {
+		t.Fatalf("got %d parts, want 1", len(parts))
+	}
+	if string(parts[0].data) != "a\nb\n" || parts[0].files != 2 {
+		t.Errorf("part = %q (%d files), want unchanged output without header", parts[0].data, parts[0].files)
+	}
+}
+
+func TestSplitParts_KeepsBlocksWhole(t *testing.T) {
+	blockA := []byte(strings.Repeat("a", 30) + "\n")
+	blockB := []byte(strings.Repeat("b", 30) + "\n")
+	blockC := []byte(strings.Repeat("c", 10) + "\n")
+	limit := len(partHeader(999, 999, "\n")) + 45
+
+	parts := splitParts([]outputBlock{{body: blockA}, {body: blockB}, {body: blockC}}, limit, "\n")
+	if len(parts) != 2 {
+		t.Fatalf("got %d parts, want 2", len(parts))
+	}
+
+	want := [][]byte{
+		append([]byte(partHeader(1, 2, "\n")), blockA...),
+		append(append([]byte(partHeader(2, 2, "\n")), blockB...), blockC...),
+	}
+	for i := range want {
+		if !bytes.Equal(parts[i].data, want[i]) {
+			t.Errorf("part %d = %q, want %q", i+1, parts[i].data, want[i])
+		}
+		if len(parts[i].data) > limit {
+			t.Errorf("part %d is %d bytes, over the %d limit", i+1, len(parts[i].data), limit)
+		}
+	}
+	if parts[1].files != 2 {
+		t.Errorf("part 2 files = %d, want 2", parts[1].files)
+	}
+}
+
+func TestSplitParts_OversizedBlockCutAtLines(t *testing.T) {
+	big := []byte(strings.Repeat("0123456789\n", 10))
+	limit := len(partHeader(999, 999, "\n")) + 25
+
+	parts := splitParts([]outputBlock{{body: big}}, limit, "\n")
+	if len(parts) != 5 {
+		t.Fatalf("got %d parts, want 5", len(parts))
+	}
+
+	var joined []byte
+	for i, p := range parts {
+		body := bytes.TrimPrefix(p.data, []byte(partHeader(i+1, len(parts), "\n")))
+		if !bytes.HasSuffix(body, []byte("\n")) {
+			t.Errorf("part %d does not end at a line boundary: %q", i+1, body)
+		}
+		joined = append(joined, body...)
+	}
+	if !bytes.Equal(joined, big) {
+		t.Errorf("rejoined parts = %q, want original block", joined)
+	}
+}
+
+func TestSplitParts_OversizedBlockKeepsFences(t *testing.T) {
+	big := outputBlock{
+		prefix:    []byte("a.txt\n```\n"),
+		continued: []byte("a.txt (continued)\n```\n"),
+		body:      []byte(strings.Repeat("0123456789\n", 10)),
+		postfix:   []byte("```\n"),
+	}
+	small := outputBlock{prefix: []byte("b.txt\n```\n"), body: []byte("b\n"), postfix: []byte("```\n")}
+	limit := len(partHeader(999, 999, "\n")) + 60
+
+	parts := splitParts([]outputBlock{big, small}, limit, "\n")
+	if len(parts) < 3 {
+		t.Fatalf("got %d parts, want the big block cut into several", len(parts))
+	}
+
+	var body []byte
+	for i, p := range parts {
+		data := bytes.TrimPrefix(p.data, []byte(partHeader(i+1, len(parts), "\n")))
+		if len(p.data) > limit {
+			t.Errorf("part %d is %d bytes, over the %d limit", i+1, len(p.data), limit)
+		}
+		if n := bytes.Count(data, []byte("```\n")); n%2 != 0 {
+			t.Errorf("part %d has an unclosed fence: %q", i+1, data)
+		}
+		want := "a.txt (continued)\n"
+		if i == 0 {
+			want = "a.txt\n"
+		}
+		if !bytes.HasPrefix(data, []byte(want)) {
+			t.Errorf("part %d = %q, want it to start with %q", i+1, data, want)
+		}
+		data, _, _ = bytes.Cut(data, []byte("b.txt"))
+		data = bytes.TrimPrefix(data, []byte(want+"```\n"))
+		body = append(body, bytes.TrimSuffix(data, []byte("```\n"))...)
+	}
+	if !bytes.Equal(body, big.body) {
+		t.Errorf("rejoined bodies = %q, want %q", body, big.body)
+	}
+	if last := parts[len(parts)-1].data; !bytes.HasSuffix(last, small.bytes()) {
+		t.Errorf("last part = %q, want it to end with the small block", last)
+	}
+}
+
+func TestSplitParts_HeadersUseNewline(t *testing.T) {
+	blocks := []outputBlock{
+		{body: []byte(strings.Repeat("a", 30) + "\r\n")},
+		{body: []byte(strings.Repeat("b", 30) + "\r\n")},
+	}
+	parts := splitParts(blocks, 50, "\r\n")
+	if len(parts) != 2 {
+		t.Fatalf("got %d parts, want 2", len(parts))
+	}
+	for i, p := range parts {
+		want := fmt.Sprintf("Part %d/2\r\n\r\n", i+1)
+		if !bytes.HasPrefix(p.data, []byte(want)) {
+			t.Errorf("part %d = %q, want it to start with %q", i+1, p.data, want)
+		}
+	}
+}
+
+func TestSplitParts_PiecesCountContentRows(t *testing.T) {
+	rows := numberRows([]byte(strings.Repeat("0123456789\n", 20)), 1)
+	rows = sliceRows(rows, 5, 5, ellipsis{template: "...{n}{skipped} skipped{n}..."})
+	block, err := Runner{LineNumbers: true}.renderView(fileView{rows: rows, totalRows: 20}, header{})
+	if err != nil {
+		t.Fatalf("renderView error: %v", err)
+	}
+
+	parts := splitParts([]outputBlock{block}, len(partHeader(999, 999, "\n"))+40, "\n")
+	if len(parts) < 3 {
+		t.Fatalf("got %d parts, want the block cut into several", len(parts))
+	}
+	total := 0
+	for _, p := range parts {
+		total += p.rows
+	}
+	if total != 10 || block.rows != 10 {
+		t.Errorf("parts count %d rows (block %d), want the 10 content rows", total, block.rows)
+	}
+}
+
+func TestSplitAtLines_LongLine(t *testing.T) {
+	chunks := splitAtLines([]byte("ab\n"+"ééééé"+"\n"), 4)
+	for _, c := range chunks {
+		if len(c) > 4 {
+			t.Errorf("chunk %q exceeds limit", c)
+		}
+		if !utf8.Valid(c) {
+			t.Errorf("chunk %q splits a rune", c)
+		}
+	}
+	if got := string(bytes.Join(chunks, nil)); got != "ab\nééééé\n" {
+		t.Errorf("rejoined chunks = %q", got)
+	}
+}
@@ -0,0 +1,51 @@
+package lx
+
+import (
+	"strconv"
+	"strings"
+)
+
+// bytesPerToken is the common rule of thumb for how many bytes of text make
+// up one LLM token.
+const bytesPerToken = 4
+
+// estimateTokens approximates the number of LLM tokens in data.
+func estimateTokens(data []byte) int {
+	return (len(data) + bytesPerToken - 1) / bytesPerToken
+}
+
+// formatThousands formats n with comma thousands separators, e.g. "1,240".
+func formatThousands(n int) string {
+	s := strconv.Itoa(n)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	var b strings.Builder
+	if neg {
+		b.WriteByte('-')
+	}
+	for i, ch := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
+
+// formatApprox formats n as an approximate, compact count such as "~950",
+// "~9.8k" or "~1.2M".
+func formatApprox(n int) string {
+	switch {
+	case n < 1000:
+		return "~" + strconv.Itoa(n)
+	case n < 1_000_000:
+		return "~" + trimZeroDecimal(float64(n)/1000) + "k"
+	default:
+		return "~" + trimZeroDecimal(float64(n)/1_000_000) + "M"
+	}
+}
+
+func trimZeroDecimal(f float64) string {
+	return strings.TrimSuffix(strconv.FormatFloat(f, 'f', 1, 64), ".0")
+}
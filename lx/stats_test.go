@@ -0,0 +1,33 @@
+package lx
+
+import "testing"
+
+func TestFormatThousands(t *testing.T) {
+	tests := map[int]string{
+		0:        "0",
+		7:        "7",
+		999:      "999",
+		1240:     "1,240",
+		1234567:  "1,234,567",
+		-1234567: "-1,234,567",
+	}
+	for n, want := range tests {
+		if got := formatThousands(n); got != want {
+			t.Errorf("formatThousands(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestFormatApprox(t *testing.T) {
+	tests := map[int]string{
+		950:     "~950",
+		1000:    "~1k",
+		9800:    "~9.8k",
+		1200000: "~1.2M",
+	}
+	for n, want := range tests {
+		if got := formatApprox(n); got != want {
+			t.Errorf("formatApprox(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package lx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+// streamChunkSize is the buffer size used when scanning files. It is a
+// variable so tests can exercise chunk boundaries.
+var streamChunkSize = 64 * 1024
+
+// errLoneCR reports a carriage return used as a line ending on its own.
+// Those files are sliced in memory, since the streaming scan splits rows at
+// line feeds only.
+var errLoneCR = errors.New("lone carriage return line ending")
+
+// streamSource is what a file is streamed from: read forwards once, then
+// read at offsets for the tail rows.
+type streamSource interface {
+	io.Reader
+	io.ReaderAt
+}
+
+// streamFile slices the file at path by streaming it. ok is false when the
+// file has to be read as a whole instead, because of its line endings.
+// A UTF-8 BOM is skipped, and UTF-16 is first transcoded to a temporary
+// file, so that memory use stays bounded for those encodings too. languages
+// are the custom mappings given to detectLanguage.
+func streamFile(path string, head, tail int, gap ellipsis, languages map[string]string) (v fileView, ok bool, err error) {
+	f, err := openFile(path)
+	if err != nil {
+		return fileView{}, false, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fileView{}, false, err
+	}
+
+	sample, err := readRange(f, 0, min(info.Size(), encodingSniffSize))
+	if err != nil {
+		return fileView{}, false, err
+	}
+
+	var (
+		src  streamSource = f
+		size              = info.Size()
+	)
+	enc := sniffEncoding(sample)
+	switch enc {
+	case encUTF8BOM:
+		size -= int64(len(bomUTF8))
+		src = io.NewSectionReader(f, int64(len(bomUTF8)), size)
+	case encUTF16LE, encUTF16BE:
+		tmp, err := transcodeUTF16(f, size, enc)
+		if err != nil {
+			return fileView{}, false, err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		if info, err = tmp.Stat(); err != nil {
+			return fileView{}, false, err
+		}
+		src, size = tmp, info.Size()
+	}
+
+	v, err = streamView(src, head, tail, gap)
+	if errors.Is(err, errLoneCR) {
+		return fileView{}, false, nil
+	}
+	if err != nil {
+		return fileView{}, false, err
+	}
+	if enc != "" {
+		v.encoding = enc
+	}
+
+	// Modelines may also sit at the end of the file.
+	start, err := readRange(src, 0, min(size, encodingSniffSize))
+	if err != nil {
+		return fileView{}, false, err
+	}
+	end, err := readRange(src, max(size-encodingSniffSize, 0), size)
+	if err != nil {
+		return fileView{}, false, err
+	}
+	v.language = detectLanguage(path, start, end, languages)
+	return v, true, nil
+}
+
+// transcodeUTF16 writes the UTF-16 file f of the given size, in the byte
+// order of enc and without its BOM, to a temporary file as UTF-8. The caller
+// removes it.
+func transcodeUTF16(f *os.File, size int64, enc string) (*os.File, error) {
+	var (
+		order binary.ByteOrder = binary.LittleEndian
+		bom                    = bomUTF16LE
+	)
+	if enc == encUTF16BE {
+		order, bom = binary.BigEndian, bomUTF16BE
+	}
+	prefix := make([]byte, len(bom))
+	n, err := f.ReadAt(prefix, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	skip := int64(0)
+	if bytes.Equal(prefix[:n], bom) {
+		skip = int64(len(bom))
+	}
+
+	tmp, err := os.CreateTemp("", "lx-*.txt")
+	if err != nil {
+		return nil, err
+	}
+	err = copyUTF16(tmp, io.NewSectionReader(f, skip, size-skip), order)
+	if err == nil {
+		// Rewind for streamView's forward pass.
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return tmp, nil
+}
+
+// streamView computes the same view as sliceRows without holding the whole
+// file in memory: head rows are kept while all rows are counted in a single
+// buffered pass, and tail rows are located by reading backwards from the end.
+// Memory use is bounded by the selected rows rather than the file size. Files
+// that turn out not to be valid UTF-8 are decoded as Windows-1252.
+func streamView(f streamSource, head, tail int, gap ellipsis) (fileView, error) {
+	br := bufio.NewReaderSize(f, streamChunkSize)
+
+	var (
+		validator   utf8Validator
+		endings     endingCounter
+		headBuf     []byte
+		headEnd     int64 // offset just past the last kept head row
+		offset      int64
+		total       int
+		rowLen      int // bytes of the current row read so far
+		longest     int
+		atLineStart = true
+	)
+
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if len(chunk) > 0 {
+			validator.write(chunk)
+			endings.write(chunk)
+			if atLineStart {
+				total++
+				rowLen = 0
+			}
+			// Row lengths exclude the line ending.
+			rowLen += len(bytes.TrimRight(chunk, "\r\n"))
+			longest = max(longest, rowLen)
+			if total <= head {
+				headBuf = append(headBuf, chunk...)
+				headEnd = offset + int64(len(chunk))
+			}
+			atLineStart = chunk[len(chunk)-1] == '\n'
+			offset += int64(len(chunk))
+		}
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			// Long row; keep reading it.
+			continue
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fileView{}, err
+		}
+	}
+
+	endings.finish()
+	if endings.cr > 0 {
+		return fileView{}, errLoneCR
+	}
+
+	// Only consider what was counted, in case the file grows meanwhile.
+	rows, err := sliceStream(f, offset, total, head, tail, headBuf, headEnd, gap)
+	if err != nil {
+		return fileView{}, err
+	}
+
+	v := fileView{
+		rows:      rows,
+		totalRows: total,
+		encoding:  encUTF8,
+		endings:   endings.kind(),
+		minified:  looksMinified(offset, total, longest),
+	}
+	if !validator.valid() {
+		for i, r := range v.rows {
+			if r.num > 0 {
+				v.rows[i].text = decodeWindows1252(r.text)
+			}
+		}
+		v.encoding = encWindows1252
+	}
+	return v, nil
+}
+
+// sliceStream assembles the head/tail rows of a scanned file of the given
+// size, reusing the head rows collected during the scan.
+func sliceStream(f io.ReaderAt, size int64, total, head, tail int, headBuf []byte, headEnd int64, gap ellipsis) ([]row, error) {
+	switch {
+	case total == 0:
+		return nil, nil
+
+	case head >= total:
+		return numberRows(headBuf, 1), nil
+
+	case (head <= 0 && tail <= 0) || tail >= total:
+		data, err := readRange(f, 0, size)
+		if err != nil {
+			return nil, err
+		}
+		return numberRows(data, 1), nil
+
+	case head > 0 && tail > 0 && head+tail >= total:
+		// The rows after the head are all part of the tail.
+		rest, err := readRange(f, headEnd, size)
+		if err != nil {
+			return nil, err
+		}
+		return numberRows(append(headBuf, rest...), 1), nil
+
+	case tail <= 0:
+		return numberRows(headBuf, 1), nil
+	}
+
+	start, err := tailStart(f, size, tail)
+	if err != nil {
+		return nil, err
+	}
+	tailBuf, err := readRange(f, start, size)
+	if err != nil {
+		return nil, err
+	}
+	tailRows := numberRows(tailBuf, total-tail+1)
+
+	if head <= 0 {
+		return tailRows, nil
+	}
+
+	rows := numberRows(headBuf, 1)
+	rows = append(rows, row{text: gap.line(head+1, total-tail, total-head-tail)})
+	return append(rows, tailRows...), nil
+}
+
+// tailStart returns the offset of the first of the last n rows of a file of
+// the given size, scanning backwards in chunks.
+func tailStart(f io.ReaderAt, size int64, n int) (int64, error) {
+	end := size
+
+	// A final newline terminates the last row rather than starting a new one.
+	if end > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, end-1); err != nil {
+			return 0, err
+		}
+		if last[0] == '\n' {
+			end--
+		}
+	}
+
+	buf := make([]byte, streamChunkSize)
+	found := 0
+	for pos := end; pos > 0; {
+		readSize := int64(len(buf))
+		if pos < readSize {
+			readSize = pos
+		}
+		pos -= readSize
+
+		chunk := buf[:readSize]
+		if _, err := f.ReadAt(chunk, pos); err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := len(chunk) - 1; i >= 0; i-- {
+			if chunk[i] != '\n' {
+				continue
+			}
+			found++
+			if found == n {
+				return pos + int64(i) + 1, nil
+			}
+		}
+	}
+	return 0, nil
+}
+
+// readRange reads the bytes in [start, end) of f.
+func readRange(f io.ReaderAt, start, end int64) ([]byte, error) {
+	if end <= start {
+		return nil, nil
+	}
+	buf := make([]byte, end-start)
+	if _, err := f.ReadAt(buf, start); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf, nil
+}
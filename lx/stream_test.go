@@ -0,0 +1,114 @@
+package lx
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStreamView_MatchesSliceRows(t *testing.T) {
+	orig := streamChunkSize
+	streamChunkSize = 16 // smallest bufio size; forces rows across chunks
+	defer func() { streamChunkSize = orig }()
+
+	inputs := []string{
+		"",
+		"one line",
+		"one line\n",
+		"a\nb\nc\nd\ne\n",
+		"a\nb\nc\nd\ne",
+		"\n\n\n",
+		"a\r\nb\r\nc\r\nd",
+		"short\n" + strings.Repeat("x", 40) + "\nmid\n" + strings.Repeat("y", 33) + "\nend",
+	}
+
+	dir := t.TempDir()
+	for i, in := range inputs {
+		path := filepath.Join(dir, "f.txt")
+		if err := os.WriteFile(path, []byte(in), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		for head := 0; head <= 6; head++ {
+			for tail := 0; tail <= 6; tail++ {
+				f, err := os.Open(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				v, err := streamView(f, head, tail, ellipsis{})
+				f.Close()
+				if err != nil {
+					t.Fatalf("input %d head %d tail %d: streamView error: %v", i, head, tail, err)
+				}
+
+				all := numberRows([]byte(in), 1)
+				want := sliceRows(all, head, tail, ellipsis{})
+				if !slices.EqualFunc(v.rows, want, equalRow) || v.totalRows != len(all) {
+					t.Errorf("input %d head %d tail %d: streamView = %q (%d rows), want %q (%d rows)",
+						i, head, tail, v.rows, v.totalRows, want, len(all))
+				}
+			}
+		}
+	}
+}
+
+func TestRunner_StreamedTailWithLineNumbers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.log")
+
+	var content strings.Builder
+	for i := 1; i <= 5000; i++ {
+		content.WriteString("log line\n")
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	r := NewRunner(1, 2, "{row_count}{n}", "", true)
+	if err := r.Run([]string{path}, &buf); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+
+	want := "5000\n1: log line\n... (4997 rows skipped)\n4999: log line\n5000: log line\n```\n\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func equalRow(a, b row) bool {
+	return a.num == b.num && bytes.Equal(a.text, b.text)
+}
+
+func TestStreamFile_BOMAndUTF16(t *testing.T) {
+	text := "first\nsecond 😀\nthird\nfourth\n"
+	tests := []struct {
+		name string
+		data []byte
+		enc  string
+	}{
+		{"utf-8 bom", append(append([]byte(nil), bomUTF8...), text...), encUTF8BOM},
+		{"utf-16le bom", utf16Bytes(text, false, true), encUTF16LE},
+		{"utf-16be no bom", utf16Bytes(text, true, false), encUTF16BE},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, "f.log")
+		if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		v, ok, err := streamFile(path, 1, 2, ellipsis{}, nil)
+		if err != nil || !ok {
+			t.Fatalf("%s: streamFile = ok %v, error %v; want it streamed", tt.name, ok, err)
+		}
+		want := sliceRows(numberRows([]byte(text), 1), 1, 2, ellipsis{})
+		if !slices.EqualFunc(v.rows, want, equalRow) || v.encoding != tt.enc {
+			t.Errorf("%s: streamFile = %q (%s), want %q (%s)", tt.name, v.rows, v.encoding, want, tt.enc)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package lx
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// findSymbol returns the 1-based, inclusive line range of the declaration
+// named by symbol. Only Go sources are supported; symbol is either a
+// top-level name ("Effective") or a method qualified by its receiver type
+// ("Options.Effective"). Doc comments are included in the range.
+func findSymbol(path string, src []byte, symbol string) (int, int, error) {
+	if languageFromPath(path) != "go" {
+		return 0, 0, fmt.Errorf("symbol %q: symbol lookup is only supported for Go files", symbol)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return 0, 0, fmt.Errorf("symbol %q: %w", symbol, err)
+	}
+
+	recv, name, isMethod := strings.Cut(symbol, ".")
+	if !isMethod {
+		name = recv
+		recv = ""
+	}
+
+	lineRange := func(doc *ast.CommentGroup, node ast.Node) (int, int, error) {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		return fset.Position(start).Line, fset.Position(node.End()).Line, nil
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Name.Name != name || receiverName(d) != recv {
+				continue
+			}
+			return lineRange(d.Doc, d)
+
+		case *ast.GenDecl:
+			if isMethod {
+				continue
+			}
+			for _, spec := range d.Specs {
+				if !specDeclares(spec, name) {
+					continue
+				}
+				// A lone spec owns the whole declaration, including its
+				// keyword and doc comment; grouped specs only cover themselves.
+				if len(d.Specs) == 1 {
+					return lineRange(d.Doc, d)
+				}
+				return lineRange(specDoc(spec), spec)
+			}
+		}
+	}
+
+	return 0, 0, fmt.Errorf("symbol %q not found", symbol)
+}
+
+// receiverName returns the receiver type name of a method, or "" for plain
+// functions.
+func receiverName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	typ := fn.Recv.List[0].Type
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.IndexListExpr:
+			typ = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+func specDeclares(spec ast.Spec, name string) bool {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return s.Name.Name == name
+	case *ast.ValueSpec:
+		for _, n := range s.Names {
+			if n.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func specDoc(spec ast.Spec) *ast.CommentGroup {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return s.Doc
+	case *ast.ValueSpec:
+		return s.Doc
+	}
+	return nil
+}
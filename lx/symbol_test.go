@@ -0,0 +1,58 @@
+package lx
+
+import (
+	"strings"
+	"testing"
+)
+
+const symbolSrc = `package demo
+
+// Greeter says hello.
+type Greeter struct{}
+
+// Hello greets name.
+func (g *Greeter) Hello(name string) string {
+	return "hello " + name
+}
+
+func Hello() {}
+
+const (
+	A = 1
+	// B is documented.
+	B = 2
+)
+`
+
+func TestFindSymbol(t *testing.T) {
+	tests := []struct {
+		symbol   string
+		from, to int
+	}{
+		{"Greeter", 3, 4},
+		{"Greeter.Hello", 6, 9},
+		{"Hello", 11, 11},
+		{"B", 15, 16},
+	}
+
+	for _, tt := range tests {
+		from, to, err := findSymbol("demo.go", []byte(symbolSrc), tt.symbol)
+		if err != nil {
+			t.Fatalf("findSymbol(%q) error: %v", tt.symbol, err)
+		}
+		if from != tt.from || to != tt.to {
+			t.Errorf("findSymbol(%q) = %d-%d, want %d-%d", tt.symbol, from, to, tt.from, tt.to)
+		}
+	}
+}
+
+func TestFindSymbol_Errors(t *testing.T) {
+	if _, _, err := findSymbol("demo.go", []byte(symbolSrc), "Missing"); err == nil ||
+		!strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	if _, _, err := findSymbol("demo.py", []byte("def f(): pass\n"), "f"); err == nil ||
+		!strings.Contains(err.Error(), "only supported for Go") {
+		t.Errorf("expected unsupported language error, got %v", err)
+	}
+}
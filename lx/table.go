@@ -0,0 +1,248 @@
+package lx
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tableSampleRows is how many records are shown from each end of a table
+// when no head or tail is given.
+const tableSampleRows = 10
+
+// tableDelimiters are the field separators tried when sniffing a table.
+var tableDelimiters = []rune{',', '\t', ';', '|'}
+
+// isTable reports whether path is a CSV or TSV file.
+func isTable(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".tsv":
+		return true
+	}
+	return false
+}
+
+// sniffDelimiter picks the separator that splits the first records of data
+// into the same number of fields, preferring the one giving the most
+// fields. Tab is tried first for .tsv files; comma is the fallback.
+func sniffDelimiter(data []byte, path string) rune {
+	candidates := tableDelimiters
+	if strings.EqualFold(filepath.Ext(path), ".tsv") {
+		candidates = []rune{'\t', ',', ';', '|'}
+	}
+
+	best, bestFields := ',', 1
+	for _, delim := range candidates {
+		cr := csv.NewReader(bytes.NewReader(data))
+		cr.Comma = delim
+		cr.LazyQuotes = true
+
+		fields := 0
+		for range 10 {
+			rec, err := cr.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				// Includes records with a differing number of fields.
+				fields = 0
+				break
+			}
+			fields = len(rec)
+		}
+		if fields > bestFields {
+			best, bestFields = delim, fields
+		}
+	}
+	return best
+}
+
+// renderTable renders CSV or TSV data as a Markdown table of the header and
+// the records kept by selectRecords, followed by a summary of the row count
+// and the inferred column types. selectRecords is given a row per record,
+// numbered by the line the record starts on and holding its source text, so
+// that row selection matches what is in the file; gap markers it returns
+// become rows of the table. With lineNumbers, record numbers are shown in a
+// leading "line" column, since a prefix before the row would break the
+// table.
+func renderTable(data []byte, path string, lineNumbers bool, selectRecords func([]row) ([]row, error)) ([]row, error) {
+	delim := sniffDelimiter(data, path)
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.Comma = delim
+	cr.LazyQuotes = true
+	cr.FieldsPerRecord = -1
+
+	var (
+		header     []string
+		headerLine int
+		body       []row
+		records    = map[int][]string{} // by line
+		types      []columnType
+		offset     int64
+	)
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse table: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		// Blank lines skipped by the reader precede the record's text.
+		text := bytes.TrimLeft(data[offset:cr.InputOffset()], "\r\n")
+		offset = cr.InputOffset()
+
+		if header == nil {
+			header, headerLine = rec, line
+			types = make([]columnType, len(header))
+			continue
+		}
+		for i := range min(len(rec), len(types)) {
+			types[i] = types[i].merge(inferColumnType(rec[i]))
+		}
+		records[line] = rec
+		body = append(body, row{text: text, num: line})
+	}
+	if header == nil {
+		return nil, nil
+	}
+
+	selected, err := selectRecords(body)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := header
+	if lineNumbers {
+		columns = append([]string{"line"}, header...)
+	}
+	rows := []row{{text: tableRow(columns, len(columns)), num: headerLine}}
+	rows = append(rows, row{text: []byte("|" + strings.Repeat(" --- |", len(columns)) + "\n")})
+	for _, rw := range selected {
+		if rw.num == 0 {
+			rows = append(rows, row{text: tableGap(rw.text, len(columns))})
+			continue
+		}
+		rec := slices.Clone(records[rw.num])
+		if rw.repeats > 1 {
+			// Annotated in the last cell, to keep the row a table row.
+			rec = append(rec, make([]string, max(len(header)-len(rec), 0))...)
+			rec[len(header)-1] += fmt.Sprintf(" (repeated %d times)", rw.repeats)
+		}
+		if lineNumbers {
+			rec = append([]string{strconv.Itoa(rw.num)}, rec...)
+		}
+		rows = append(rows, row{text: tableRow(rec, len(columns)), num: rw.num})
+	}
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "\n%s rows × %s columns (%s-separated)\n",
+		formatThousands(len(body)), formatThousands(len(header)), delimiterName(delim))
+	for i, name := range header {
+		fmt.Fprintf(&summary, "- %s: %s\n", name, types[i])
+	}
+	return append(rows, row{text: []byte(summary.String())}), nil
+}
+
+// tableRow renders a record as a Markdown table row of width cells.
+func tableRow(rec []string, width int) []byte {
+	var b bytes.Buffer
+	b.WriteString("|")
+	for i := range width {
+		cell := ""
+		if i < len(rec) {
+			cell = rec[i]
+		}
+		cell = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ").Replace(cell)
+		b.WriteString(" " + cell + " |")
+	}
+	b.WriteString("\n")
+	return b.Bytes()
+}
+
+// tableGap renders a gap marker as a table row of width cells, so that it
+// doesn't end the table: the marker text followed by ellipses.
+func tableGap(marker []byte, width int) []byte {
+	cells := make([]string, width)
+	cells[0] = strings.TrimSpace(string(marker))
+	for i := 1; i < width; i++ {
+		cells[i] = "…"
+	}
+	return tableRow(cells, width)
+}
+
+func delimiterName(delim rune) string {
+	switch delim {
+	case '\t':
+		return "tab"
+	case ';':
+		return "semicolon"
+	case '|':
+		return "pipe"
+	}
+	return "comma"
+}
+
+// columnType is the type inferred for a table column from its values.
+type columnType string
+
+const (
+	colEmpty   columnType = ""
+	colInteger columnType = "integer"
+	colNumber  columnType = "number"
+	colBoolean columnType = "boolean"
+	colDate    columnType = "date"
+	colText    columnType = "text"
+)
+
+func (t columnType) String() string {
+	if t == colEmpty {
+		return "empty"
+	}
+	return string(t)
+}
+
+// merge combines the types of two sets of values of the same column.
+func (t columnType) merge(u columnType) columnType {
+	switch {
+	case t == u || u == colEmpty:
+		return t
+	case t == colEmpty:
+		return u
+	case (t == colInteger || t == colNumber) && (u == colInteger || u == colNumber):
+		return colNumber
+	}
+	return colText
+}
+
+// inferColumnType returns the narrowest type that fits value.
+func inferColumnType(value string) columnType {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return colEmpty
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return colInteger
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return colNumber
+	}
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no":
+		return colBoolean
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05"} {
+		if _, err := time.Parse(layout, value); err == nil {
+			return colDate
+		}
+	}
+	return colText
+}
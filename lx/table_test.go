@@ -0,0 +1,154 @@
+package lx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sliceRecords selects the first head and last tail records of a table.
+func sliceRecords(head, tail int) func([]row) ([]row, error) {
+	return func(records []row) ([]row, error) {
+		return sliceRows(records, head, tail, ellipsis{}), nil
+	}
+}
+
+func TestSniffDelimiter(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		path string
+		want rune
+	}{
+		{"comma", "a,b,c\n1,2,3\n", "x.csv", ','},
+		{"semicolon", "a;b;c\n1,5;2;3\n", "x.csv", ';'},
+		{"tab", "a\tb\n1\t2\n", "x.csv", '\t'},
+		{"quoted commas", "a|b\n\"x,y,z\"|2\n", "x.csv", '|'},
+		{"single column", "name\nalice\n", "x.csv", ','},
+		{"single column tsv", "name\nalice\n", "x.tsv", ','},
+	}
+
+	for _, tt := range tests {
+		if got := sniffDelimiter([]byte(tt.data), tt.path); got != tt.want {
+			t.Errorf("%s: sniffDelimiter = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInferColumnType(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   string
+	}{
+		{[]string{"1", "-20", ""}, "integer"},
+		{[]string{"1", "2.5"}, "number"},
+		{[]string{"true", "No"}, "boolean"},
+		{[]string{"2024-05-01", "2024-05-01T10:00:00Z"}, "date"},
+		{[]string{"1", "n/a"}, "text"},
+		{[]string{"", " "}, "empty"},
+	}
+
+	for _, tt := range tests {
+		var got columnType
+		for _, v := range tt.values {
+			got = got.merge(inferColumnType(v))
+		}
+		if got.String() != tt.want {
+			t.Errorf("type of %q = %s, want %s", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	var data strings.Builder
+	data.WriteString("id\tnote\n")
+	for i := 1; i <= 6; i++ {
+		data.WriteString(strings.Repeat("x", i) + "\ta|b\n")
+	}
+
+	rows, err := renderTable([]byte(data.String()), "data.tsv", false, sliceRecords(1, 1))
+	if err != nil {
+		t.Fatalf("renderTable error: %v", err)
+	}
+	want := "| id | note |\n" +
+		"| --- | --- |\n" +
+		"| x | a\\|b |\n" +
+		"| ... (4 rows skipped) | … |\n" +
+		"| xxxxxx | a\\|b |\n" +
+		"\n6 rows × 2 columns (tab-separated)\n" +
+		"- id: text\n" +
+		"- note: text\n"
+	if got := string(joinRows(rows)); got != want {
+		t.Errorf("renderTable = %q, want %q", got, want)
+	}
+	if rows[0].num != 1 || rows[2].num != 2 || rows[4].num != 7 {
+		t.Errorf("row numbers = %d, %d, %d, want 1, 2, 7", rows[0].num, rows[2].num, rows[4].num)
+	}
+}
+
+func TestRenderTable_SamplesByDefault(t *testing.T) {
+	var data strings.Builder
+	data.WriteString("n\n")
+	for i := range 100 {
+		data.WriteString(strings.Repeat("1", i%5+1) + "\n")
+	}
+
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(path, []byte(data.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := Options{Tables: true}.Effective().readView(path)
+	if err != nil {
+		t.Fatalf("readView error: %v", err)
+	}
+	if got := contentRows(v.rows); got != 1+2*tableSampleRows {
+		t.Errorf("readView kept %d rows, want the header and %d sampled records", got, 2*tableSampleRows)
+	}
+	if got := string(joinRows(v.rows)); !strings.Contains(got, "| ... (80 rows skipped) |") ||
+		!strings.Contains(got, "100 rows × 1 columns") || !strings.Contains(got, "- n: integer") {
+		t.Errorf("readView = %q, want skipped rows and a summary", got)
+	}
+}
+
+func TestRenderTable_LineNumberColumn(t *testing.T) {
+	data := "id,name\n1,a\n2,b\n3,c\n"
+
+	rows, err := renderTable([]byte(data), "data.csv", true, sliceRecords(1, 1))
+	if err != nil {
+		t.Fatalf("renderTable error: %v", err)
+	}
+	want := "| line | id | name |\n" +
+		"| --- | --- | --- |\n" +
+		"| 2 | 1 | a |\n" +
+		"| ... (1 rows skipped) | … | … |\n" +
+		"| 4 | 3 | c |\n"
+	if got := string(joinRows(rows)); !strings.HasPrefix(got, want) {
+		t.Errorf("renderTable = %q, want it to start with %q", got, want)
+	}
+}
+
+func TestReadView_TableAppliesRowFilters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	data := "id,name\n1,n1\n2,n2\n2,n2\n3,x3\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := Options{Tables: true, DropLines: "x", DedupeLines: true}.Effective()
+	v, err := r.readView(path)
+	if err != nil {
+		t.Fatalf("readView error: %v", err)
+	}
+	want := "| id | name |\n" +
+		"| --- | --- |\n" +
+		"| 1 | n1 |\n" +
+		"| 2 | n2 (repeated 2 times) |\n"
+	if got := string(joinRows(v.rows)); !strings.HasPrefix(got, want) {
+		t.Errorf("readView = %q, want it to start with %q", got, want)
+	}
+	if v.dropped != 1 {
+		t.Errorf("dropped = %d, want 1", v.dropped)
+	}
+}
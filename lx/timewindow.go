@@ -0,0 +1,200 @@
+package lx
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// logTimeFormat recognizes a timestamp at the start of a log line.
+type logTimeFormat struct {
+	re      *regexp.Regexp // first submatch is the timestamp
+	layouts []string
+	noYear  bool // syslog timestamps leave out the year
+}
+
+// logTimeFormats lists the timestamp formats detected by --since/--until.
+// Timestamps without a zone are read as local time.
+var logTimeFormats = []logTimeFormat{
+	{
+		// RFC 3339 and ISO 8601-like, optionally bracketed:
+		// 2024-05-01T14:32:07.123Z, 2024-05-01 14:32:07,123
+		re: regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?)`),
+		layouts: []string{
+			"2006-01-02T15:04:05.999999999Z07:00",
+			"2006-01-02T15:04:05.999999999Z0700",
+			"2006-01-02T15:04:05.999999999",
+			"2006-01-02 15:04:05.999999999Z07:00",
+			"2006-01-02 15:04:05.999999999Z0700",
+			"2006-01-02 15:04:05.999999999",
+		},
+	},
+	{
+		// Go log package default and nginx error logs: 2024/05/01 14:32:07
+		re:      regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?)`),
+		layouts: []string{"2006/01/02 15:04:05.999999999"},
+	},
+	{
+		// syslog: May  1 14:32:07
+		re:      regexp.MustCompile(`^([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2})`),
+		layouts: []string{"Jan _2 15:04:05"},
+		noYear:  true,
+	},
+	{
+		// Apache and nginx access logs:
+		// 127.0.0.1 - - [01/May/2024:14:32:07 +0000] "GET / HTTP/1.1" ...
+		re:      regexp.MustCompile(`^\S+ \S+ \S+ \[(\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4})\]`),
+		layouts: []string{"02/Jan/2006:15:04:05 -0700"},
+	},
+}
+
+// lineTime returns the timestamp at the start of line. Syslog timestamps
+// get the year that puts them closest before now.
+func lineTime(line []byte, now time.Time) (time.Time, bool) {
+	for _, f := range logTimeFormats {
+		m := f.re.FindSubmatch(line)
+		if m == nil {
+			continue
+		}
+		// Some loggers separate fractional seconds with a comma.
+		value := strings.Replace(string(m[1]), ",", ".", 1)
+		for _, layout := range f.layouts {
+			t, err := time.ParseInLocation(layout, value, time.Local)
+			if err != nil {
+				continue
+			}
+			if f.noYear {
+				t = t.AddDate(now.Year(), 0, 0)
+				if t.After(now.Add(24 * time.Hour)) {
+					t = t.AddDate(-1, 0, 0)
+				}
+			}
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// timeBound is a --since or --until value. Times of day without a date are
+// resolved against the date of the first timestamp in each file.
+type timeBound struct {
+	t         time.Time
+	timeOfDay bool
+}
+
+// timeBoundLayouts are the absolute forms accepted by --since and --until.
+var timeBoundLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseTimeBound parses a --since or --until value: a date, a date and time,
+// a time of day such as "14:32", or a duration such as "15m" meaning that
+// long before now.
+func parseTimeBound(s string, now time.Time) (timeBound, error) {
+	for _, layout := range timeBoundLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return timeBound{t: t}, nil
+		}
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return timeBound{t: t, timeOfDay: true}, nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return timeBound{t: now.Add(-d)}, nil
+	}
+	return timeBound{}, fmt.Errorf("invalid time %q: use a date, date and time, time of day (14:32) or duration (15m)", s)
+}
+
+func validateTimeBound(s string) error {
+	_, err := parseTimeBound(s, time.Now())
+	return err
+}
+
+// resolve returns the bound as an absolute time, taking the date of a time
+// of day from day.
+func (b timeBound) resolve(day time.Time) time.Time {
+	if !b.timeOfDay {
+		return b.t
+	}
+	y, m, d := day.Date()
+	return time.Date(y, m, d, b.t.Hour(), b.t.Minute(), b.t.Second(), 0, day.Location())
+}
+
+// timeWindow keeps the log entries timestamped between since and until,
+// inclusive. Either bound may be nil to leave that side open.
+type timeWindow struct {
+	since, until *timeBound
+}
+
+// parseTimeWindow parses the --since and --until values, returning nil when
+// both are empty.
+func parseTimeWindow(since, until string, now time.Time) (*timeWindow, error) {
+	if since == "" && until == "" {
+		return nil, nil
+	}
+
+	var w timeWindow
+	for _, b := range []struct {
+		flag, value string
+		dst         **timeBound
+	}{{"--since", since, &w.since}, {"--until", until, &w.until}} {
+		if b.value == "" {
+			continue
+		}
+		bound, err := parseTimeBound(b.value, now)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", b.flag, err)
+		}
+		*b.dst = &bound
+	}
+	return &w, nil
+}
+
+// filter keeps the content rows of log entries inside the window. A row
+// without a timestamp continues the entry above it; rows before the first
+// timestamp are kept only when the window has no start. Separate runs of
+// kept rows are joined by gap markers.
+func (w timeWindow) filter(rows []row, gap ellipsis, now time.Time) []row {
+	var (
+		since, until time.Time
+		resolved     bool
+		in           = w.since == nil
+		out          []row
+		last         = -1 // index of the last kept row
+	)
+
+	for i, r := range rows {
+		if r.num > 0 {
+			if t, ok := lineTime(bytes.TrimRight(r.text, "\r\n"), now); ok {
+				if !resolved {
+					if w.since != nil {
+						since = w.since.resolve(t)
+					}
+					if w.until != nil {
+						until = w.until.resolve(t)
+					}
+					resolved = true
+				}
+				in = (w.since == nil || !t.Before(since)) && (w.until == nil || !t.After(until))
+			}
+		}
+		if !in {
+			continue
+		}
+		if last >= 0 {
+			out = appendGap(out, rows[last+1:i], gap)
+		}
+		out = append(out, r)
+		last = i
+	}
+	return out
+}
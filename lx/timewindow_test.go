@@ -0,0 +1,103 @@
+package lx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLineTime(t *testing.T) {
+	now := time.Date(2024, time.May, 2, 9, 0, 0, 0, time.Local)
+	local := func(mo time.Month, d, h, mi, s int) time.Time {
+		return time.Date(2024, mo, d, h, mi, s, 0, time.Local)
+	}
+
+	tests := []struct {
+		line string
+		want time.Time
+		ok   bool
+	}{
+		{"2024-05-01T14:32:07Z INFO start", time.Date(2024, time.May, 1, 14, 32, 7, 0, time.UTC), true},
+		{"2024-05-01T14:32:07.250+02:00 x", time.Date(2024, time.May, 1, 12, 32, 7, 250e6, time.UTC), true},
+		{"2024-05-01 14:32:07,123 ERROR x", local(time.May, 1, 14, 32, 7).Add(123 * time.Millisecond), true},
+		{"[2024-05-01 14:32:07] x", local(time.May, 1, 14, 32, 7), true},
+		{"2024/05/01 14:32:07 listening", local(time.May, 1, 14, 32, 7), true},
+		{"May  1 14:32:07 host sshd[1]: x", local(time.May, 1, 14, 32, 7), true},
+		{"Dec 31 23:59:59 host x", time.Date(2023, time.December, 31, 23, 59, 59, 0, time.Local), true},
+		{`10.0.0.1 - - [01/May/2024:14:32:07 +0000] "GET / HTTP/1.1" 200`, time.Date(2024, time.May, 1, 14, 32, 7, 0, time.UTC), true},
+		{"\tat main.main(main.go:12)", time.Time{}, false},
+		{"request took 2024-05-01T14:32:07Z", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := lineTime([]byte(tt.line), now)
+		if ok != tt.ok || ok && !got.Equal(tt.want) {
+			t.Errorf("lineTime(%q) = %v, %v; want %v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2024, time.May, 2, 9, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		value     string
+		want      time.Time
+		timeOfDay bool
+	}{
+		{"2024-05-01T14:30:00Z", time.Date(2024, time.May, 1, 14, 30, 0, 0, time.UTC), false},
+		{"2024-05-01 14:30", time.Date(2024, time.May, 1, 14, 30, 0, 0, time.Local), false},
+		{"2024-05-01", time.Date(2024, time.May, 1, 0, 0, 0, 0, time.Local), false},
+		{"15m", now.Add(-15 * time.Minute), false},
+		{"14:30", time.Date(2024, time.May, 1, 14, 30, 0, 0, time.Local), true},
+	}
+
+	day := time.Date(2024, time.May, 1, 3, 0, 0, 0, time.Local)
+	for _, tt := range tests {
+		b, err := parseTimeBound(tt.value, now)
+		if err != nil {
+			t.Fatalf("parseTimeBound(%q) error: %v", tt.value, err)
+		}
+		if got := b.resolve(day); !got.Equal(tt.want) || b.timeOfDay != tt.timeOfDay {
+			t.Errorf("parseTimeBound(%q) = %v (time of day %v), want %v (%v)",
+				tt.value, got, b.timeOfDay, tt.want, tt.timeOfDay)
+		}
+	}
+
+	for _, bad := range []string{"yesterday", "-5m", "25:00"} {
+		if _, err := parseTimeBound(bad, now); err == nil {
+			t.Errorf("parseTimeBound(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestTimeWindow_Filter(t *testing.T) {
+	now := time.Date(2024, time.May, 2, 9, 0, 0, 0, time.Local)
+	data := []byte("preamble\n" +
+		"2024/05/01 14:20:00 start\n" +
+		"2024/05/01 14:31:00 request\n" +
+		"2024/05/01 14:32:07 panic: boom\n" +
+		"goroutine 1 [running]:\n" +
+		"\tmain.main()\n" +
+		"2024/05/01 14:50:00 restart\n")
+
+	tests := []struct {
+		since, until string
+		want         string
+	}{
+		{"14:30", "14:40", "3:2024/05/01 14:31:00 request 4:2024/05/01 14:32:07 panic: boom " +
+			"5:goroutine 1 [running]: 6:\tmain.main()"},
+		{"", "14:25", "1:preamble 2:2024/05/01 14:20:00 start"},
+		{"2024-05-01 14:45", "", "7:2024/05/01 14:50:00 restart"},
+	}
+
+	for _, tt := range tests {
+		w, err := parseTimeWindow(tt.since, tt.until, now)
+		if err != nil {
+			t.Fatalf("parseTimeWindow(%q, %q) error: %v", tt.since, tt.until, err)
+		}
+		got := viewString(w.filter(numberRows(data, 1), ellipsis{template: "{from}-{to}"}, now))
+		if got != tt.want {
+			t.Errorf("since %q until %q: filter = %q, want %q", tt.since, tt.until, got, tt.want)
+		}
+	}
+}
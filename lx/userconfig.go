@@ -0,0 +1,109 @@
+package lx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UserConfig holds settings from the user's config file, which is read from
+// $LX_CONFIG or <user config dir>/lx/config. The format is "key = value"
+// lines with '#' comments:
+//
+//	# command used by --copy instead of auto-detection
+//	clipboard = xclip -selection clipboard
+//
+//	# fence languages for extensions and file names
+//	language .tpl = gotemplate
+//	language Jenkinsfile.ci = groovy
+type UserConfig struct {
+	Clipboard string
+
+	// Languages maps lowercased extensions and file names to fence
+	// languages.
+	Languages map[string]string
+}
+
+// userConfigPath returns the config file location, or "" if none applies.
+func userConfigPath() string {
+	if p := os.Getenv("LX_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "lx", "config")
+}
+
+// LoadUserConfig reads the user's config file. A missing file yields the zero
+// config.
+func LoadUserConfig() (UserConfig, error) {
+	path := userConfigPath()
+	if path == "" {
+		return UserConfig{}, nil
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return UserConfig{}, nil
+	}
+	if err != nil {
+		return UserConfig{}, fmt.Errorf("open config: %w", err)
+	}
+	defer f.Close()
+
+	cfg, err := parseUserConfig(f)
+	if err != nil {
+		return UserConfig{}, fmt.Errorf("config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func parseUserConfig(rd io.Reader) (UserConfig, error) {
+	var cfg UserConfig
+
+	sc := bufio.NewScanner(rd)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return UserConfig{}, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if name, ok := strings.CutPrefix(key, "language "); ok {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" || value == "" {
+				return UserConfig{}, fmt.Errorf("line %d: expected language <extension or file name> = <language>", lineNo)
+			}
+			if cfg.Languages == nil {
+				cfg.Languages = map[string]string{}
+			}
+			cfg.Languages[name] = value
+			continue
+		}
+
+		switch key {
+		case "clipboard":
+			cfg.Clipboard = value
+		default:
+			return UserConfig{}, fmt.Errorf("line %d: unknown key %q", lineNo, key)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return UserConfig{}, err
+	}
+
+	return cfg, nil
+}
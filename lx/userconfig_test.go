@@ -0,0 +1,60 @@
+package lx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseUserConfig(t *testing.T) {
+	in := "# my settings\n\nclipboard = xclip -selection clipboard\n"
+	cfg, err := parseUserConfig(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("parseUserConfig error: %v", err)
+	}
+	if cfg.Clipboard != "xclip -selection clipboard" {
+		t.Errorf("Clipboard = %q, want %q", cfg.Clipboard, "xclip -selection clipboard")
+	}
+
+	for _, bad := range []string{"clipboard\n", "colour = red\n", "language = go\n", "language .tpl =\n"} {
+		if _, err := parseUserConfig(strings.NewReader(bad)); err == nil {
+			t.Errorf("parseUserConfig(%q) expected error", bad)
+		}
+	}
+}
+
+func TestParseUserConfig_Languages(t *testing.T) {
+	in := "language .TPL = gotemplate\nlanguage Jenkinsfile.ci = groovy\n"
+	cfg, err := parseUserConfig(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("parseUserConfig error: %v", err)
+	}
+	want := map[string]string{".tpl": "gotemplate", "jenkinsfile.ci": "groovy"}
+	if !reflect.DeepEqual(cfg.Languages, want) {
+		t.Errorf("Languages = %v, want %v", cfg.Languages, want)
+	}
+}
+
+func TestLoadUserConfig_FromEnv(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config")
+	if err := os.WriteFile(path, []byte("clipboard = pbcopy\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LX_CONFIG", path)
+
+	cfg, err := LoadUserConfig()
+	if err != nil {
+		t.Fatalf("LoadUserConfig error: %v", err)
+	}
+	if cfg.Clipboard != "pbcopy" {
+		t.Errorf("Clipboard = %q, want %q", cfg.Clipboard, "pbcopy")
+	}
+
+	t.Setenv("LX_CONFIG", filepath.Join(dir, "missing"))
+	if cfg, err := LoadUserConfig(); err != nil || cfg.Clipboard != "" || cfg.Languages != nil {
+		t.Errorf("LoadUserConfig with missing file = %+v, %v; want zero config", cfg, err)
+	}
+}
@@ -0,0 +1,108 @@
+package lx
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// fileStamp is the part of a file's metadata that changes when it is edited.
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func stampFiles(paths []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			stamps[p] = fileStamp{}
+			continue
+		}
+		stamps[p] = fileStamp{exists: true, size: info.Size(), modTime: info.ModTime()}
+	}
+	return stamps
+}
+
+// restampFiles returns stamps for paths, keeping those already in before and
+// stamping only paths that are new.
+func restampFiles(paths []string, before map[string]fileStamp) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(paths))
+	var added []string
+	for _, p := range paths {
+		if s, ok := before[p]; ok {
+			stamps[p] = s
+		} else {
+			added = append(added, p)
+		}
+	}
+	for p, s := range stampFiles(added) {
+		stamps[p] = s
+	}
+	return stamps
+}
+
+func stampsEqual(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for p, s := range a {
+		if t, ok := b[p]; !ok || s != t {
+			return false
+		}
+	}
+	return true
+}
+
+// watchFiles polls the files returned by paths every interval and calls
+// render once changes have settled for at least debounce. paths is re-read
+// after every render so that the watched set can follow manifest edits, and
+// files are compared against how they were when the render started, so that
+// edits made while it runs trigger another.
+// watchFiles returns nil when ctx is cancelled and stops early only if
+// render asks it to by returning a non-nil error.
+func watchFiles(ctx context.Context, paths func() []string, interval, debounce time.Duration, render func() error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	return watchTicks(ctx, ticker.C, paths, debounce, render)
+}
+
+// watchTicks is watchFiles polling on every tick, with the debounce measured
+// between tick times, so that tests can drive it without a real clock.
+func watchTicks(ctx context.Context, ticks <-chan time.Time, paths func() []string, debounce time.Duration, render func() error) error {
+	watched := paths()
+	last := stampFiles(watched)
+
+	var (
+		pending    bool
+		lastChange time.Time
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticks:
+			cur := stampFiles(watched)
+			if !stampsEqual(cur, last) {
+				last = cur
+				pending = true
+				lastChange = now
+				continue
+			}
+
+			if !pending || now.Sub(lastChange) < debounce {
+				continue
+			}
+			pending = false
+
+			if err := render(); err != nil {
+				return err
+			}
+			watched = paths()
+			last = restampFiles(watched, cur)
+		}
+	}
+}
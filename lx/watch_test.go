@@ -0,0 +1,132 @@
+package lx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchTicks_RendersOnceAfterChangesSettle(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "f.txt")
+	if err := os.WriteFile(path, []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var renders atomic.Int32
+	ticks := make(chan time.Time)
+	done := make(chan error, 1)
+	go func() {
+		done <- watchTicks(ctx, ticks, func() []string { return []string{path} },
+			50*time.Millisecond, func() error {
+				renders.Add(1)
+				return nil
+			})
+	}()
+
+	// Ticks are unbuffered, so a send returns once the tick before it has
+	// been handled, and only the tick times count towards the debounce. A
+	// write made right after a send may be seen by that tick or the next.
+	start := time.Unix(0, 0)
+	tick := func(ms int) {
+		ticks <- start.Add(time.Duration(ms) * time.Millisecond)
+	}
+
+	// A burst of writes should be debounced into a single render.
+	tick(0)
+	for i, content := range []string{"ab\n", "abc\n", "abcd\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		tick(10 * (i + 1))
+	}
+	// The last write is seen at 20ms or 30ms, so nothing renders before
+	// 70ms; the 50ms tick has been handled once the 60ms one is sent.
+	for ms := 40; ms <= 60; ms += 10 {
+		tick(ms)
+	}
+	if n := renders.Load(); n != 0 {
+		t.Errorf("got %d renders before changes settled, want 0", n)
+	}
+	for ms := 70; ms <= 300; ms += 10 {
+		tick(ms)
+	}
+	if n := renders.Load(); n != 1 {
+		t.Errorf("got %d renders, want 1", n)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("watchTicks returned %v, want nil", err)
+	}
+}
+
+func TestWatchTicks_RendersAgainAfterChangeDuringRender(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "f.txt")
+	if err := os.WriteFile(path, []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var renders atomic.Int32
+	ticks := make(chan time.Time)
+	done := make(chan error, 1)
+	go func() {
+		done <- watchTicks(ctx, ticks, func() []string { return []string{path} },
+			50*time.Millisecond, func() error {
+				// The first render is slow enough to see another save.
+				if renders.Add(1) == 1 {
+					return os.WriteFile(path, []byte("abc\n"), 0o644)
+				}
+				return nil
+			})
+	}()
+
+	start := time.Unix(0, 0)
+	tick := func(ms int) {
+		ticks <- start.Add(time.Duration(ms) * time.Millisecond)
+	}
+
+	tick(0)
+	if err := os.WriteFile(path, []byte("ab\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for ms := 10; ms <= 300; ms += 10 {
+		tick(ms)
+	}
+	if n := renders.Load(); n != 2 {
+		t.Errorf("got %d renders, want 2", n)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("watchTicks returned %v, want nil", err)
+	}
+}
+
+func TestStampsEqual_DetectsRemoval(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "f.txt")
+	if err := os.WriteFile(path, []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	before := stampFiles([]string{path})
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	after := stampFiles([]string{path})
+
+	if stampsEqual(before, after) {
+		t.Errorf("stampsEqual reported no change after removing %s", path)
+	}
+}